@@ -10,11 +10,38 @@ endfunction
 
 call remote#host#Register('vigor', 'x', function('s:RequireVigor'))
 call remote#host#RegisterPlugin('vigor', '0', [
-\ {'type': 'autocmd', 'name': 'BufReadCmd', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH}, ''Cwd'': getcwd(), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%'')}', 'pattern': 'godoc://**'}},
-\ {'type': 'command', 'name': 'Fmt', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH}, ''Bufnr'': bufnr(''%'')}', 'range': '%'}},
-\ {'type': 'command', 'name': 'Godef', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH}, ''Cwd'': getcwd(), ''Bufnr'': bufnr(''%'')}', 'nargs': '*'}},
-\ {'type': 'command', 'name': 'Godoc', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH}, ''Cwd'': getcwd(), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%'')}', 'nargs': '*'}},
-\ {'type': 'function', 'name': 'QQQDocComplete', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH}, ''Cwd'': getcwd(), ''Bufnr'': bufnr(''%'')}'}},
+\ {'type': 'autocmd', 'name': 'BufReadCmd', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Options'': {''RuntimeNotes'': get(g:, ''vigor_runtime_notes'', 0), ''FullImportPaths'': get(b:, ''vigor_full_import_paths'', get(g:, ''vigor_full_import_paths'', 0)), ''MaxElements'': get(g:, ''vigor_max_elements'', 100), ''MaxStringLength'': get(g:, ''vigor_max_string_length'', 128), ''Full'': get(b:, ''vigor_full'', 0), ''Detail'': get(b:, ''vigor_detail'', get(g:, ''vigor_detail'', 2)), ''LoadTimeout'': get(g:, ''vigor_load_timeout'', 10000), ''VarValues'': get(g:, ''vigor_var_values'', 0), ''ExampleStatus'': get(g:, ''vigor_example_status'', 0), ''FoldDeclLines'': get(g:, ''vigor_fold_decl_lines'', 12), ''HideExamples'': get(b:, ''vigor_hide_examples'', get(g:, ''vigor_hide_examples'', 0)), ''StdPackages'': get(g:, ''vigor_std_packages'', ''first''), ''Index'': get(g:, ''vigor_index'', '''')}, ''Cwd'': getcwd(), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%''), ''Label'': {''Prefix'': get(g:, ''vigor_label_prefix'', ''[godoc] ''), ''Statusline'': get(g:, ''vigor_statusline_label'', 0)}}', 'pattern': 'godoc://**'}},
+\ {'type': 'autocmd', 'name': 'BufWritePost', 'sync': 0, 'opts': {'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufWritePre', 'sync': 1, 'opts': {'eval': '{''Enabled'': get(g:, ''vigor_format_on_save'', 0), ''Abuf'': str2nr(expand(''<abuf>'')), ''Format'': {''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Bufnr'': bufnr(''%''), ''Command'': get(g:, ''vigor_fmt_command'', []), ''Local'': get(g:, ''vigor_goimports_local'', '''')}}', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'FocusGained', 'sync': 0, 'opts': {'pattern': '*'}},
+\ {'type': 'command', 'name': 'Fmt', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Bufnr'': bufnr(''%''), ''Command'': get(g:, ''vigor_fmt_command'', []), ''Local'': get(g:, ''vigor_goimports_local'', '''')}', 'range': '%'}},
+\ {'type': 'command', 'name': 'Gocoverage', 'sync': 1, 'opts': {'complete': 'dir', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Dir'': expand(''%:p:h'')}', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'Godef', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%''), ''Line'': line(''.''), ''Col'': col(''.'')}', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Godoc', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%''), ''DefaultPackage'': get(g:, ''vigor_default_package'', ''.''), ''Panel'': {''Position'': get(g:, ''vigor_panel'', ''''), ''Size'': get(g:, ''vigor_panel_size'', 0)}}', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Godocbin', 'sync': 1, 'opts': {'complete': 'file', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd()}', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'Godocbuffers', 'sync': 1, 'opts': {}},
+\ {'type': 'command', 'name': 'Godoccall', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Name'': expand(''%:p''), ''Bufnr'': bufnr(''%''), ''Line'': line(''.''), ''Col'': col(''.''), ''Panel'': {''Position'': get(g:, ''vigor_panel'', ''''), ''Size'': get(g:, ''vigor_panel_size'', 0)}}'}},
+\ {'type': 'command', 'name': 'Godocexamples', 'sync': 1, 'opts': {'eval': '{''Name'': expand(''%'')}'}},
+\ {'type': 'command', 'name': 'Godocgrep', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Name'': expand(''%'')}', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'Godocoverview', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'Godocreturn', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Name'': expand(''%:p''), ''Bufnr'': bufnr(''%''), ''Line'': line(''.''), ''Col'': col(''.''), ''Panel'': {''Position'': get(g:, ''vigor_panel'', ''''), ''Size'': get(g:, ''vigor_panel_size'', 0)}}'}},
+\ {'type': 'command', 'name': 'Godocsnippet', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Options'': {''RuntimeNotes'': get(g:, ''vigor_runtime_notes'', 0), ''FullImportPaths'': get(b:, ''vigor_full_import_paths'', get(g:, ''vigor_full_import_paths'', 0)), ''MaxElements'': get(g:, ''vigor_max_elements'', 100), ''MaxStringLength'': get(g:, ''vigor_max_string_length'', 128), ''Full'': get(b:, ''vigor_full'', 0), ''Detail'': get(b:, ''vigor_detail'', get(g:, ''vigor_detail'', 2)), ''LoadTimeout'': get(g:, ''vigor_load_timeout'', 10000), ''VarValues'': get(g:, ''vigor_var_values'', 0), ''ExampleStatus'': get(g:, ''vigor_example_status'', 0), ''FoldDeclLines'': get(g:, ''vigor_fold_decl_lines'', 12), ''HideExamples'': get(b:, ''vigor_hide_examples'', get(g:, ''vigor_hide_examples'', 0)), ''StdPackages'': get(g:, ''vigor_std_packages'', ''first''), ''Index'': get(g:, ''vigor_index'', '''')}, ''Bufnr'': bufnr(''%'')}', 'range': '%'}},
+\ {'type': 'command', 'name': 'Godocsym', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Name'': expand(''%'')}', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'Godocsymnext', 'sync': 1, 'opts': {'eval': '{''Name'': expand(''%'')}'}},
+\ {'type': 'command', 'name': 'Godocsymprev', 'sync': 1, 'opts': {'eval': '{''Name'': expand(''%'')}'}},
+\ {'type': 'command', 'name': 'Godocuse', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Name'': expand(''%''), ''Bufnr'': bufnr(''%''), ''Line'': line(''.''), ''Col'': col(''.'')}'}},
+\ {'type': 'command', 'name': 'Gofmt', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Bufnr'': bufnr(''%''), ''Command'': get(g:, ''vigor_fmt_command'', []), ''Local'': get(g:, ''vigor_goimports_local'', '''')}', 'range': '%'}},
+\ {'type': 'command', 'name': 'Goimpl', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Goref', 'sync': 1, 'opts': {'bang': '', 'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Gosigdiff', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Gosymbols', 'sync': 1, 'opts': {'complete': 'customlist,QQQDocComplete', 'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'Govet', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Dir'': expand(''%:p:h'')}'}},
+\ {'type': 'command', 'name': 'Gowhatis', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Name'': expand(''%:p''), ''Bufnr'': bufnr(''%''), ''Line'': line(''.''), ''Col'': col(''.'')}'}},
+\ {'type': 'command', 'name': 'Vigorconfig', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Options'': {''RuntimeNotes'': get(g:, ''vigor_runtime_notes'', 0), ''FullImportPaths'': get(b:, ''vigor_full_import_paths'', get(g:, ''vigor_full_import_paths'', 0)), ''MaxElements'': get(g:, ''vigor_max_elements'', 100), ''MaxStringLength'': get(g:, ''vigor_max_string_length'', 128), ''Full'': get(b:, ''vigor_full'', 0), ''Detail'': get(b:, ''vigor_detail'', get(g:, ''vigor_detail'', 2)), ''LoadTimeout'': get(g:, ''vigor_load_timeout'', 10000), ''VarValues'': get(g:, ''vigor_var_values'', 0), ''ExampleStatus'': get(g:, ''vigor_example_status'', 0), ''FoldDeclLines'': get(g:, ''vigor_fold_decl_lines'', 12), ''HideExamples'': get(b:, ''vigor_hide_examples'', get(g:, ''vigor_hide_examples'', 0)), ''StdPackages'': get(g:, ''vigor_std_packages'', ''first''), ''Index'': get(g:, ''vigor_index'', '''')}, ''Completion'': {''Fuzzy'': get(g:, ''vigor_fuzzy_completion'', 0), ''Scope'': get(g:, ''vigor_completion_scope'', ''all'')}, ''UpKey'': get(g:, ''vigor_up_key'', ''-'')}'}},
+\ {'type': 'command', 'name': 'Vigorreset', 'sync': 1, 'opts': {}},
+\ {'type': 'function', 'name': 'QQQDocComplete', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%''), ''Completion'': {''Fuzzy'': get(g:, ''vigor_fuzzy_completion'', 0), ''Scope'': get(g:, ''vigor_completion_scope'', ''all'')}}'}},
+\ {'type': 'function', 'name': 'VigorBufferLabel', 'sync': 1, 'opts': {'eval': '{''Label'': {''Prefix'': get(g:, ''vigor_label_prefix'', ''[godoc] ''), ''Statusline'': get(g:, ''vigor_statusline_label'', 0)}, ''Name'': bufname(''%'')}'}},
+\ {'type': 'function', 'name': 'VigorSymbolIndex', 'sync': 1, 'opts': {'eval': '{''Env'': {''GOROOT'': $GOROOT, ''GOPATH'': $GOPATH, ''GOOS'': $GOOS, ''GOARCH'': $GOARCH, ''Offline'': get(g:, ''vigor_offline'', 0), ''Tags'': get(g:, ''vigor_build_tags'', [])}, ''Cwd'': getcwd(), ''Dir'': expand(''%:p:h''), ''Bufnr'': bufnr(''%'')}'}},
 \ ])
 
 " vim:ts=4:sw=4:et
@@ -6,16 +6,20 @@
 package main
 
 import (
+	"github.com/garyburd/vigor/src/coverage"
 	"github.com/garyburd/vigor/src/explore"
 	"github.com/garyburd/vigor/src/format"
+	"github.com/garyburd/vigor/src/vet"
 
 	"github.com/neovim/go-client/nvim/plugin"
 )
 
 func main() {
 	plugin.Main(func(p *plugin.Plugin) error {
+		coverage.Register(p)
 		explore.Register(p)
 		format.Register(p)
+		vet.Register(p)
 		return nil
 	})
 }
@@ -19,6 +19,28 @@ type Env struct {
 	GOPATH string `eval:"$GOPATH"`
 	GOOS   string `eval:"$GOOS"`
 	GOARCH string `eval:"$GOARCH"`
+
+	// Offline prevents the go commands run by the plugin from accessing the
+	// network.
+	Offline bool `eval:"get(g:, 'vigor_offline', 0)"`
+
+	// Tags are the build tags used to select the files in a package.
+	Tags []string `eval:"get(g:, 'vigor_build_tags', [])"`
+}
+
+// equal returns true if the environments are the same.
+func (env *Env) equal(other *Env) bool {
+	if env.GOROOT != other.GOROOT || env.GOPATH != other.GOPATH ||
+		env.GOOS != other.GOOS || env.GOARCH != other.GOARCH ||
+		env.Offline != other.Offline || len(env.Tags) != len(other.Tags) {
+		return false
+	}
+	for i := range env.Tags {
+		if env.Tags[i] != other.Tags[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type Context struct {
@@ -37,11 +59,19 @@ var (
 	mu  sync.Mutex
 )
 
+// Clear discards the cached context. The next call to Get creates a new
+// context from the current process environment.
+func Clear() {
+	mu.Lock()
+	ctx = nil
+	mu.Unlock()
+}
+
 // Get returns a context for the specified environment.
 func Get(env *Env) *Context {
 	mu.Lock()
 	defer mu.Unlock()
-	if ctx != nil && *ctx.env == *env {
+	if ctx != nil && ctx.env.equal(env) {
 		return ctx
 	}
 	m := make(map[string]string)
@@ -67,6 +97,16 @@ func Get(env *Env) *Context {
 		ctx.Build.GOARCH = env.GOARCH
 		m["GOARCH"] = "GOARCH=" + env.GOARCH
 	}
+	if len(env.Tags) > 0 {
+		ctx.Build.BuildTags = append([]string(nil), env.Tags...)
+	}
+	if env.Offline {
+		// Documentation lookups read GOPATH and the module cache directly.
+		// Turn off the module proxy and checksum database so that go vet and
+		// goimports fail fast instead of downloading missing modules.
+		m["GOPROXY"] = "GOPROXY=off"
+		m["GOSUMDB"] = "GOSUMDB=off"
+	}
 	for _, e := range m {
 		ctx.Environ = append(ctx.Environ, e)
 	}
@@ -0,0 +1,86 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package coverage implements the :Gocoverage command.
+package coverage
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/garyburd/vigor/src/context"
+	"github.com/garyburd/vigor/src/quickfix"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/neovim/go-client/nvim/plugin"
+)
+
+func Register(p *plugin.Plugin) {
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gocoverage", NArgs: "?", Complete: "dir", Eval: "*"}, coverage)
+}
+
+func coverage(v *nvim.Nvim, args []string, eval *struct {
+	Env context.Env
+	Dir string `eval:"expand('%:p:h')"`
+}) error {
+	pkg := "."
+	if len(args) > 0 {
+		pkg = args[0]
+	}
+	var out bytes.Buffer
+	c := exec.Command("go", "test", "-cover", pkg)
+	c.Dir = eval.Dir
+	c.Stdout = &out
+	c.Stderr = &out
+	c.Env = context.Get(&eval.Env).Environ
+	err := c.Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		if qfl := parseFailures(out.Bytes(), eval.Dir); len(qfl) > 0 {
+			return quickfix.Set(v, qfl)
+		}
+		return errors.New(string(bytes.TrimSpace(out.Bytes())))
+	} else if err != nil {
+		return err
+	}
+	summary := parseSummary(out.Bytes())
+	if len(summary) == 0 {
+		return errors.New("go test: no coverage reported")
+	}
+	return v.WriteOut(strings.Join(summary, "\n") + "\n")
+}
+
+// indentRx matches the indentation of the test failure lines printed by go
+// test.
+var indentRx = regexp.MustCompile(`(?m)^[ \t]+`)
+
+// parseFailures returns the "file:line: message" errors in the output of a
+// failed go test. File names are interpreted relative to dir.
+func parseFailures(out []byte, dir string) []*nvim.QuickfixError {
+	return quickfix.Parse(indentRx.ReplaceAll(out, nil), dir, 0)
+}
+
+// summaryRx matches the package result lines printed by go test -cover. The
+// submatches are the import path, the coverage note and the percentage.
+var summaryRx = regexp.MustCompile(`^(?:ok  |\?   |)\t(\S+)\t.*(coverage: (\S+%) of statements|\[no statements\]|\[no test files\])`)
+
+// parseSummary returns a line of the form "import/path: 85.0%" for each
+// package result in the output of go test -cover.
+func parseSummary(out []byte) []string {
+	var summary []string
+	for _, line := range strings.Split(string(out), "\n") {
+		m := summaryRx.FindStringSubmatch(line)
+		switch {
+		case m == nil:
+			continue
+		case m[3] != "":
+			summary = append(summary, m[1]+": "+m[3])
+		default:
+			summary = append(summary, m[1]+": "+strings.Trim(m[2], "[]"))
+		}
+	}
+	return summary
+}
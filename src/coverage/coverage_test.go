@@ -0,0 +1,64 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package coverage
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFailures(t *testing.T) {
+	const out = "--- FAIL: TestX (0.00s)\n" +
+		"    x_test.go:6: bad value\n" +
+		"    --- FAIL: TestX/sub (0.00s)\n" +
+		"        x_test.go:8: got 1\n" +
+		"FAIL\n" +
+		"coverage: [no statements]\n" +
+		"FAIL\texample.com/ft\t0.003s\n" +
+		"FAIL\n"
+	dir := filepath.FromSlash("/src/ft")
+	var got []string
+	for _, qfe := range parseFailures([]byte(out), dir) {
+		got = append(got, fmt.Sprintf("%s:%d %s", qfe.FileName, qfe.LNum, qfe.Text))
+	}
+	fname := filepath.Join(dir, "x_test.go")
+	want := []string{fname + ":6 bad value", fname + ":8 got 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFailures() = %q, want %q", got, want)
+	}
+}
+
+var parseSummaryTests = []struct {
+	out  string
+	want []string
+}{
+	{
+		"ok  \texample.com/a\t0.002s\tcoverage: 66.7% of statements\n",
+		[]string{"example.com/a: 66.7%"},
+	},
+	{
+		"ok  \texample.com/a\t(cached)\tcoverage: 66.7% of statements\n" +
+			"ok  \texample.com/c\t0.002s\tcoverage: [no statements]\n" +
+			"?   \texample.com/b\t[no test files]\n" +
+			"\texample.com/d\t\tcoverage: 0.0% of statements\n",
+		[]string{"example.com/a: 66.7%", "example.com/c: no statements", "example.com/b: no test files", "example.com/d: 0.0%"},
+	},
+	{
+		"PASS\ncoverage: 50.0% of statements\nok  \texample.com/a\t0.002s\tcoverage: 50.0% of statements\n",
+		[]string{"example.com/a: 50.0%"},
+	},
+	{"", nil},
+}
+
+func TestParseSummary(t *testing.T) {
+	for _, tt := range parseSummaryTests {
+		got := parseSummary([]byte(tt.out))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSummary(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
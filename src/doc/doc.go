@@ -8,9 +8,12 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"os"
+	"path"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/neovim/go-client/nvim/plugin"
@@ -25,11 +28,13 @@ type Doc struct {
 	folds      []*fold
 	highlights []*highlight
 	anchors    map[string][2]int
+	vars       map[string]interface{}
 
 	buf                       bytes.Buffer
 	index                     map[string]int
 	highlightStack, linkStack []stackElement
 	foldStack                 []position
+	codeStack                 []position
 
 	// Fields used by outputPosition
 	lineNum    int
@@ -41,21 +46,105 @@ func NewDoc() *Doc {
 	return &Doc{
 		index:      make(map[string]int),
 		anchors:    make(map[string][2]int),
-		data:       &data{},
+		vars:       make(map[string]interface{}),
+		data:       &data{watch: make(map[string]time.Time)},
 		lineNum:    1,
 		lineOffset: -1,
 	}
 }
 
+// Bytes returns the text of the document.
+func (d *Doc) Bytes() []byte { return d.buf.Bytes() }
+
 func (d *Doc) WriteString(s string) (int, error) { return d.buf.WriteString(s) }
 
 func (d *Doc) Write(p []byte) (int, error) { return d.buf.Write(p) }
 
+// AddAnchor adds an anchor with the given name at the current position. If
+// the document already has an anchor with the name, then the first anchor is
+// kept so that links to the name resolve to the same position regardless of
+// the declarations that follow.
 func (d *Doc) AddAnchor(name string) {
+	if _, ok := d.anchors[name]; ok {
+		return
+	}
 	address := d.outputPosition()
 	d.anchors[name] = [2]int{address.line(), address.column()}
 }
 
+// AddDecl records the current position as the start of a declaration for
+// the ]] and [[ motions. Only the first declaration on a line is recorded.
+func (d *Doc) AddDecl() {
+	p := d.outputPosition()
+	if n := len(d.data.decls); n > 0 && d.data.decls[n-1].line() == p.line() {
+		return
+	}
+	d.data.decls = append(d.data.decls, p)
+}
+
+// Decls returns the 1-based lines and columns of the declarations recorded
+// with AddDecl.
+func (d *Doc) Decls() [][2]int {
+	decls := make([][2]int, len(d.data.decls))
+	for i, p := range d.data.decls {
+		decls[i] = [2]int{p.line(), p.column()}
+	}
+	return decls
+}
+
+// Line returns the 1-based line of the current position.
+func (d *Doc) Line() int { return d.outputPosition().line() }
+
+// Anchor returns the 1-based line and column of the anchor with the given
+// name.
+func (d *Doc) Anchor(name string) (int, int, bool) {
+	a, ok := d.anchors[name]
+	return a[0], a[1], ok
+}
+
+// Watch adds the files to the set of files checked for changes. The document
+// is rendered again when one of the files is modified.
+func (d *Doc) Watch(fnames ...string) {
+	for _, fname := range fnames {
+		var t time.Time
+		if fi, err := os.Stat(fname); err == nil {
+			t = fi.ModTime()
+		}
+		d.data.watch[fname] = t
+	}
+}
+
+// SetVar sets the buffer variable b:name to value when the document is
+// displayed.
+func (d *Doc) SetVar(name string, value interface{}) {
+	d.vars[name] = value
+}
+
+// Var returns the value set by SetVar for b:name or nil if the variable is
+// not set.
+func (d *Doc) Var(name string) interface{} {
+	return d.vars[name]
+}
+
+// Highlighted returns the text highlighted with group. The text of a
+// highlight spanning lines is returned one line at a time.
+func (d *Doc) Highlighted(group string) []string {
+	lines := strings.Split(d.buf.String(), "\n")
+	var texts []string
+	for _, h := range d.bufferHighlights() {
+		if h.group != group || h.line >= len(lines) {
+			continue
+		}
+		line := lines[h.line]
+		end := h.colEnd
+		if end < 0 || end > len(line) {
+			end = len(line)
+		}
+		texts = append(texts, line[h.colStart:end])
+	}
+	return texts
+}
+
 func (d *Doc) PushFold() {
 	d.foldStack = append(d.foldStack, d.outputPosition())
 }
@@ -70,9 +159,50 @@ func (d *Doc) PopFold() {
 	if end.column() == 1 {
 		lend--
 	}
-	if lend > lstart {
-		d.folds = append(d.folds, &fold{start: lstart, end: lend})
+	d.AddFold(lstart, lend)
+}
+
+// AddFold folds the 1-based lines start through end. Folds of a single line
+// are ignored.
+func (d *Doc) AddFold(start, end int) {
+	if end > start {
+		d.folds = append(d.folds, &fold{start: start, end: end})
+	}
+}
+
+// Fold returns the lines of the innermost fold containing line.
+func (d *Doc) Fold(line int) (int, int, bool) {
+	var found *fold
+	for _, f := range d.folds {
+		if f.start <= line && line <= f.end && (found == nil || f.start >= found.start && f.end <= found.end) {
+			found = f
+		}
+	}
+	if found == nil {
+		return 0, 0, false
 	}
+	return found.start, found.end, true
+}
+
+// PushCode starts a block of source code that can be yanked with the gY
+// mapping.
+func (d *Doc) PushCode() {
+	d.codeStack = append(d.codeStack, d.outputPosition())
+}
+
+// PopCode ends the block started by the matching call to PushCode. The code
+// is yanked when the cursor is in the block. The gX mapping opens the source
+// of the code at line in the file path.
+func (d *Doc) PopCode(code string, path string, line int) {
+	start := d.codeStack[len(d.codeStack)-1]
+	d.codeStack = d.codeStack[:len(d.codeStack)-1]
+	end := d.outputPosition()
+
+	lend := end.line()
+	if end.column() == 1 {
+		lend--
+	}
+	d.data.code = append(d.data.code, &codeBlock{start: start.line(), end: lend, code: code, path: path, line: line})
 }
 
 func (d *Doc) PushLinkAnchor(path string, anchor string) {
@@ -166,6 +296,36 @@ func (d *Doc) outputPosition() position {
 type data struct {
 	strings []string
 	links   []*link
+	code    []*codeBlock
+
+	// Decls are the positions of the declarations in page order.
+	decls []position
+
+	// Sources are the links from the anchored declarations on the page to
+	// the positions of the declarations in the source files, in page order.
+	sources []*link
+
+	// Watched files and their modification times.
+	watch map[string]time.Time
+
+	// Stale is set when a watched file is changed while the document is not
+	// displayed in a window.
+	stale bool
+}
+
+// changed returns true if a watched file was modified, created or deleted.
+func (d *data) changed() bool {
+	for fname, t := range d.watch {
+		fi, err := os.Stat(fname)
+		if err != nil {
+			if !t.IsZero() {
+				return true
+			}
+		} else if !fi.ModTime().Equal(t) {
+			return true
+		}
+	}
+	return false
 }
 
 // position encodes a line and column as a single integer
@@ -208,6 +368,30 @@ type highlight struct {
 	group string
 }
 
+// bufferHighlight is a highlight in a single buffer line. The line and
+// columns are zero based. The end column is -1 for the end of the line.
+type bufferHighlight struct {
+	group            string
+	line             int
+	colStart, colEnd int
+}
+
+// bufferHighlights splits the document highlights into the single line
+// highlights added to the buffer by Display.
+func (d *Doc) bufferHighlights() []bufferHighlight {
+	var hs []bufferHighlight
+	for _, h := range d.highlights {
+		lstart, cstart := h.start.line(), h.start.column()
+		lend, cend := h.end.line(), h.end.column()
+		for l := lstart; l < lend; l++ {
+			hs = append(hs, bufferHighlight{h.group, l - 1, cstart - 1, -1})
+			cstart = 1
+		}
+		hs = append(hs, bufferHighlight{h.group, lend - 1, cstart - 1, cend - 1})
+	}
+	return hs
+}
+
 func (e *highlight) appendCopy(d *Doc, start, end position) {
 	d.highlights = append(d.highlights, &highlight{start: start, end: end, group: e.group})
 }
@@ -218,16 +402,143 @@ type fold struct {
 	start, end int
 }
 
+// codeBlock represents a block of lines containing source code.
+type codeBlock struct {
+	// Start and end lines
+	start, end int
+
+	// The code without the indentation used in the document.
+	code string
+
+	// The file and line of the source.
+	path string
+	line int
+}
+
+// setSources sets the links from the anchors in anchors to positions in
+// source files as the sources of the page.
+func (d *data) setSources(anchors map[string][2]int) {
+	starts := make(map[position]bool, len(anchors))
+	for _, a := range anchors {
+		starts[newPosition(a[0], a[1])] = true
+	}
+	d.sources = nil
+	for _, l := range d.links {
+		if starts[l.start] && l.address.line() > 0 {
+			d.sources = append(d.sources, l)
+		}
+	}
+}
+
+// findSource returns the source link of the declaration at or above line or
+// nil if there is no such declaration.
+func (d *data) findSource(line int) *link {
+	i := sort.Search(len(d.sources), func(i int) bool {
+		return d.sources[i].start.line() > line
+	})
+	if i == 0 {
+		return nil
+	}
+	return d.sources[i-1]
+}
+
+// nextDecl returns the position of the declaration after the 1-based line
+// and column or before the position if backward is set. The search wraps
+// around at the ends of the page.
+func (d *data) nextDecl(line, col int, backward bool) (position, bool) {
+	n := len(d.decls)
+	if n == 0 {
+		return 0, false
+	}
+	p := newPosition(line, col)
+	if backward {
+		i := sort.Search(n, func(i int) bool { return d.decls[i] >= p })
+		if i == 0 {
+			i = n
+		}
+		return d.decls[i-1], true
+	}
+	i := sort.Search(n, func(i int) bool { return d.decls[i] > p })
+	if i == n {
+		i = 0
+	}
+	return d.decls[i], true
+}
+
+// findCode returns the block containing line or nil if there is no such
+// block.
+func (d *data) findCode(line int) *codeBlock {
+	for _, c := range d.code {
+		if c.start <= line && line <= c.end {
+			return c
+		}
+	}
+	return nil
+}
+
 type windowHighlight struct {
 	id   int
 	link *link
 }
 
+// ReloadCmd renders the document in the current buffer again.
+const ReloadCmd = `let w:vigor_view = winsaveview() | execute 'doautocmd BufReadCmd ' . fnameescape(bufname('%')) | call winrestview(w:vigor_view)`
+
+// checkDelay is the time to wait for more changes before checking the
+// watched files.
+const checkDelay = 500 * time.Millisecond
+
 type Manager struct {
 	nvim       *nvim.Nvim
 	mu         sync.Mutex
 	docs       map[int]*data
 	highlights map[nvim.Window]*windowHighlight
+	checkTimer *time.Timer
+	history    history
+}
+
+// location is a position in a document recorded before following a link.
+type location struct {
+	buf       int
+	name      string
+	line, col int
+	topline   int
+}
+
+// maxHistory is the maximum number of locations in the history.
+const maxHistory = 100
+
+// history is a stack of the locations of followed links.
+type history []location
+
+// push adds loc to the top of the stack. The oldest location is dropped
+// when the stack is full.
+func (h *history) push(loc location) {
+	if len(*h) >= maxHistory {
+		*h = (*h)[1:]
+	}
+	*h = append(*h, loc)
+}
+
+// pop removes and returns the location at the top of the stack.
+func (h *history) pop() (location, bool) {
+	if len(*h) == 0 {
+		return location{}, false
+	}
+	loc := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return loc, true
+}
+
+// deleteBuffer removes the locations in buffer b.
+func (h *history) deleteBuffer(b int) {
+	locs := (*h)[:0]
+	for _, loc := range *h {
+		if loc.buf != b {
+			locs = append(locs, loc)
+		}
+	}
+	*h = locs
 }
 
 func NewManager(p *plugin.Plugin) *Manager {
@@ -235,12 +546,36 @@ func NewManager(p *plugin.Plugin) *Manager {
 	p.Handle("doc.onUpdateHighlight", m.onUpdateHighlight)
 	p.Handle("doc.onBufDelete", m.onBufDelete)
 	p.Handle("doc.onJump", m.onJump)
+	p.Handle("doc.onBack", m.onBack)
+	p.Handle("doc.onUp", m.onUp)
+	p.Handle("doc.onWinEnter", m.onWinEnter)
+	p.Handle("doc.onYankCode", m.onYankCode)
+	p.Handle("doc.onOpenCode", m.onOpenCode)
+	p.Handle("doc.onOpenSource", m.onOpenSource)
+	p.Handle("doc.onNextDecl", m.onNextDecl)
+	p.Handle("doc.onPrevDecl", m.onPrevDecl)
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWritePost", Pattern: "*.go"}, m.onFileChange)
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "FocusGained", Pattern: "*"}, m.onFileChange)
 	return m
 }
 
+// Buffers returns the numbers of the buffers displaying documents in
+// increasing order.
+func (m *Manager) Buffers() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bufs := make([]int, 0, len(m.docs))
+	for b := range m.docs {
+		bufs = append(bufs, b)
+	}
+	sort.Ints(bufs)
+	return bufs
+}
+
 func (m *Manager) onBufDelete(b int) {
 	m.mu.Lock()
 	delete(m.docs, b)
+	m.history.deleteBuffer(b)
 	m.mu.Unlock()
 }
 
@@ -249,6 +584,12 @@ func (m *Manager) onJump(b, line, col int) error {
 	if link == nil {
 		return nil
 	}
+	if p := d.strings[link.path]; isURL(p) {
+		return m.nvim.Command(fmt.Sprintf("call netrw#BrowseX(%q, 0)", p))
+	}
+	if err := m.pushLocation(b, line, col); err != nil {
+		return err
+	}
 	var cmds []string
 	if p := d.strings[link.path]; p != "" {
 		cmds = append(cmds, fmt.Sprintf("edit %s", p))
@@ -264,6 +605,100 @@ func (m *Manager) onJump(b, line, col int) error {
 	return m.nvim.Command(strings.Join(cmds, "| "))
 }
 
+// pushLocation records the location in buffer b displayed in the current
+// window for onBack.
+func (m *Manager) pushLocation(b, line, col int) error {
+	name, err := m.nvim.BufferName(nvim.Buffer(b))
+	if err != nil {
+		return err
+	}
+	var topline int
+	if err := m.nvim.Eval("line('w0')", &topline); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.history.push(location{buf: b, name: name, line: line, col: col, topline: topline})
+	m.mu.Unlock()
+	return nil
+}
+
+// onBack returns to the location before the last followed link. The cursor
+// and the scroll position are restored.
+func (m *Manager) onBack() error {
+	m.mu.Lock()
+	loc, ok := m.history.pop()
+	m.mu.Unlock()
+	if !ok {
+		return m.nvim.Command("echo 'No previous location'")
+	}
+	return m.nvim.Command(fmt.Sprintf("edit %s | call winrestview({'lnum': %d, 'col': %d, 'topline': %d})",
+		fnameEscape(loc.name), loc.line, loc.col-1, loc.topline))
+}
+
+// fnameEscape escapes the special characters in the file name for the edit
+// command.
+func fnameEscape(name string) string {
+	return strings.NewReplacer(" ", "\\ ", "%", "\\%", "#", "\\#", "|", "\\|").Replace(name)
+}
+
+// onUp opens the page above the page in buffer b. The page above a symbol is
+// the symbol's package and the page above a package is the parent directory.
+func (m *Manager) onUp(b int) error {
+	name, err := m.nvim.BufferName(nvim.Buffer(b))
+	if err != nil {
+		return err
+	}
+	up, ok := upURI(name)
+	if !ok {
+		return nil
+	}
+	return m.nvim.Command("edit " + up)
+}
+
+// parseURI splits a page URI of the form scheme://path#symbol into its
+// parts. The symbol is optional.
+func parseURI(uri string) (scheme, path, symbol string, ok bool) {
+	i := strings.Index(uri, "://")
+	if i < 0 {
+		return "", "", "", false
+	}
+	scheme, path = uri[:i], uri[i+len("://"):]
+	if i := strings.Index(path, "#"); i >= 0 {
+		path, symbol = path[:i], path[i+1:]
+	}
+	return scheme, path, symbol, true
+}
+
+// upURI returns the URI of the page above the page with the given URI.
+func upURI(uri string) (string, bool) {
+	scheme, p, symbol, ok := parseURI(uri)
+	switch {
+	case !ok:
+		return "", false
+	case symbol != "":
+		// Symbol to package.
+	case p == "":
+		return "", false
+	default:
+		local := strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../")
+		p = path.Dir(p)
+		switch {
+		case p == "." || p == "/":
+			p = ""
+		case local && !strings.HasPrefix(p, "."):
+			// Restore the prefix removed by path.Dir.
+			p = "./" + p
+		}
+	}
+	return scheme + "://" + p, true
+}
+
+// isURL returns true if the link path p is a web URL. Web URLs are opened
+// with the netrw browser.
+func isURL(p string) bool {
+	return strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://")
+}
+
 func (m *Manager) onUpdateHighlight(b, line, col int) error {
 
 	_, newLink := m.findLink(b, line, col)
@@ -288,12 +723,15 @@ func (m *Manager) onUpdateHighlight(b, line, col int) error {
 
 	if hl != nil {
 		delete(m.highlights, w)
-		if err := m.nvim.Call("matchdelete", nil, hl.id); err != nil {
-			return err
-		}
+		// Ignore the error for a match removed by clearmatches() or
+		// similar.
+		m.nvim.Call("matchdelete", nil, hl.id)
 	}
 
 	if newLink != nil {
+		if wins, err := m.nvim.Windows(); err == nil {
+			m.pruneHighlights(wins)
+		}
 		hl := &windowHighlight{link: newLink}
 		m.highlights[w] = hl
 		if err := m.nvim.Call("matchaddpos", &hl.id, "Underlined", [][3]int{{newLink.start.line(), newLink.start.column(), int(newLink.end - newLink.start)}}); err != nil {
@@ -304,6 +742,227 @@ func (m *Manager) onUpdateHighlight(b, line, col int) error {
 	return nil
 }
 
+// pruneHighlights forgets the highlights for windows that are not in wins.
+// An entry is left behind when a window is closed without leaving the
+// document buffer, as when the window is closed from another window. The
+// caller must hold m.mu.
+func (m *Manager) pruneHighlights(wins []nvim.Window) {
+	open := make(map[nvim.Window]bool, len(wins))
+	for _, w := range wins {
+		open[w] = true
+	}
+	for w := range m.highlights {
+		if !open[w] {
+			delete(m.highlights, w)
+		}
+	}
+}
+
+// onFileChange schedules a check of the watched files. Changes in quick
+// succession are checked once.
+func (m *Manager) onFileChange() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkTimer != nil {
+		m.checkTimer.Stop()
+	}
+	m.checkTimer = time.AfterFunc(checkDelay, m.checkFiles)
+}
+
+// checkFiles renders the documents with modified files again.
+func (m *Manager) checkFiles() {
+	m.mu.Lock()
+	var bufs []int
+	for b, d := range m.docs {
+		if !d.stale && d.changed() {
+			bufs = append(bufs, b)
+		}
+	}
+	m.mu.Unlock()
+	m.reload(bufs)
+}
+
+// Clear discards the state of the manager that is not needed to display the
+// documents. Link highlights are removed, the history of followed links is
+// cleared and pending file checks are canceled. The documents are rendered again, now for documents displayed in
+// a window and on WinEnter for other documents.
+func (m *Manager) Clear() {
+	m.mu.Lock()
+	if m.checkTimer != nil {
+		m.checkTimer.Stop()
+		m.checkTimer = nil
+	}
+	highlights := m.highlights
+	m.highlights = make(map[nvim.Window]*windowHighlight)
+	m.history = nil
+	var bufs []int
+	for b := range m.docs {
+		bufs = append(bufs, b)
+	}
+	m.mu.Unlock()
+
+	for w, hl := range highlights {
+		// Ignore the error for a match or window that no longer exists.
+		m.nvim.Call("matchdelete", nil, hl.id, w)
+	}
+	m.reload(bufs)
+}
+
+// reload renders the documents in buffers bufs again. Documents not
+// displayed in a window are marked as stale and rendered again by onWinEnter.
+func (m *Manager) reload(bufs []int) {
+	for _, b := range bufs {
+		var wins []int
+		if err := m.nvim.Call("win_findbuf", &wins, b); err != nil {
+			log.Println("reload:", err)
+			continue
+		}
+		if len(wins) == 0 {
+			m.mu.Lock()
+			if d := m.docs[b]; d != nil {
+				d.stale = true
+			}
+			m.mu.Unlock()
+			continue
+		}
+		if err := m.nvim.Call("win_execute", nil, wins[0], ReloadCmd); err != nil {
+			log.Println("reload:", err)
+		}
+	}
+}
+
+// onWinEnter restores the highlight for the link under the cursor when a
+// documentation buffer is displayed in a window or a window displaying the
+// buffer is entered. The window local match for the highlight is lost when
+// the buffer leaves the window and may have been cleared by other commands.
+func (m *Manager) onWinEnter(b, line, col int) error {
+	m.mu.Lock()
+	d := m.docs[b]
+	m.mu.Unlock()
+	if d != nil && d.stale {
+		return m.nvim.Command(ReloadCmd)
+	}
+
+	w, err := m.nvim.CurrentWindow()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	hl := m.highlights[w]
+	delete(m.highlights, w)
+	m.mu.Unlock()
+	if hl != nil {
+		// Ignore the error for a match that no longer exists.
+		m.nvim.Call("matchdelete", nil, hl.id)
+	}
+	return m.onUpdateHighlight(b, line, col)
+}
+
+// onYankCode yanks the code in the block containing line to register.
+func (m *Manager) onYankCode(b, line int, register string) error {
+	m.mu.Lock()
+	d := m.docs[b]
+	m.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	c := d.findCode(line)
+	if c == nil {
+		return m.nvim.Command("echo 'No code under cursor'")
+	}
+	if err := m.nvim.Call("setreg", nil, register, c.code, "l"); err != nil {
+		return err
+	}
+	return m.nvim.Command(fmt.Sprintf("echo '%d lines yanked'", strings.Count(c.code, "\n")))
+}
+
+// onOpenCode opens the source of the code in the block containing line. If
+// line is not in a block, then the source of the first block is opened.
+func (m *Manager) onOpenCode(b, line int) error {
+	m.mu.Lock()
+	d := m.docs[b]
+	m.mu.Unlock()
+	if d == nil || len(d.code) == 0 {
+		return m.nvim.Command("echo 'No code on page'")
+	}
+	c := d.findCode(line)
+	if c == nil {
+		c = d.code[0]
+	}
+	return m.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, 1)", c.path, c.line))
+}
+
+// onOpenSource opens the source of the declaration at or above line. The
+// cursor does not need to be on the declared name.
+func (m *Manager) onOpenSource(b, line, col int) error {
+	m.mu.Lock()
+	d := m.docs[b]
+	m.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	link := d.findSource(line)
+	if link == nil {
+		return m.nvim.Command("echo 'No declaration above cursor'")
+	}
+	if err := m.pushLocation(b, line, col); err != nil {
+		return err
+	}
+	return m.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)",
+		fnameEscape(d.strings[link.path]), link.address.line(), link.address.column()))
+}
+
+// onNextDecl moves the cursor to the next declaration on the page.
+func (m *Manager) onNextDecl(b, line, col int) error {
+	return m.moveToDecl(b, line, col, false)
+}
+
+// onPrevDecl moves the cursor to the previous declaration on the page.
+func (m *Manager) onPrevDecl(b, line, col int) error {
+	return m.moveToDecl(b, line, col, true)
+}
+
+func (m *Manager) moveToDecl(b, line, col int, backward bool) error {
+	m.mu.Lock()
+	d := m.docs[b]
+	m.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	p, ok := d.nextDecl(line, col, backward)
+	if !ok {
+		return m.nvim.Command("echo 'No declarations on page'")
+	}
+	return m.nvim.Command(fmt.Sprintf(`execute "normal! m'" | call cursor(%d, %d)`, p.line(), p.column()))
+}
+
+// LinkTarget is the target of a link. The target is a file or page path and
+// either an anchor on the page or a position in the file.
+type LinkTarget struct {
+	Path         string
+	Anchor       string
+	Line, Column int
+}
+
+// Link returns the target of the link at line and col in buffer b.
+func (m *Manager) Link(b, line, col int) (LinkTarget, bool) {
+	d, link := m.findLink(b, line, col)
+	if link == nil {
+		return LinkTarget{}, false
+	}
+	return d.linkTarget(link), true
+}
+
+func (d *data) linkTarget(link *link) LinkTarget {
+	t := LinkTarget{Path: d.strings[link.path]}
+	if l, c := link.address.line(), link.address.column(); l > 0 {
+		t.Line, t.Column = l, c
+	} else if c >= 0 {
+		t.Anchor = d.strings[c]
+	}
+	return t
+}
+
 func (m *Manager) findLink(b, line, col int) (*data, *link) {
 	m.mu.Lock()
 	d := m.docs[b]
@@ -340,24 +999,28 @@ func (m *Manager) Display(d *Doc, buf nvim.Buffer) error {
 	b.Command("autocmd! * <buffer>")
 	b.Command(fmt.Sprintf("autocmd BufDelete <buffer> call rpcnotify(%d, 'doc.onBufDelete', bufnr('%%'))", m.nvim.ChannelID()))
 	b.Command(fmt.Sprintf("autocmd CursorMoved <buffer> call rpcrequest(%d, 'doc.onUpdateHighlight', bufnr('%%'), line('.'), col('.'))", m.nvim.ChannelID()))
-	b.Command(fmt.Sprintf("autocmd BufWinLeave <buffer> call rpcrequest(%d, 'doc.onUpdateHighlight', bufnr('%%'), -1, -1)", m.nvim.ChannelID()))
+	b.Command(fmt.Sprintf("autocmd BufLeave,BufWinLeave <buffer> call rpcrequest(%d, 'doc.onUpdateHighlight', bufnr('%%'), -1, -1)", m.nvim.ChannelID()))
+	b.Command(fmt.Sprintf("autocmd BufWinEnter,WinEnter <buffer> call rpcrequest(%d, 'doc.onWinEnter', bufnr('%%'), line('.'), col('.'))", m.nvim.ChannelID()))
 	b.ClearBufferHighlight(buf, -1, 0, -1)
-	for _, h := range d.highlights {
-		lstart, cstart := h.start.line(), h.start.column()
-		lend, cend := h.end.line(), h.end.column()
-		for l := lstart; l < lend; l++ {
-			var id int
-			b.AddBufferHighlight(buf, -1, h.group, l-1, cstart-1, -1, &id)
-			cstart = 1
-		}
+	for _, h := range d.bufferHighlights() {
 		var id int
-		b.AddBufferHighlight(buf, -1, h.group, lend-1, cstart-1, cend-1, &id)
+		b.AddBufferHighlight(buf, -1, h.group, h.line, h.colStart, h.colEnd, &id)
 	}
 	for _, f := range d.folds {
 		b.Command(fmt.Sprintf("%d,%dfold", f.start, f.end))
 	}
 	b.SetBufferVar(buf, "anchors", d.anchors)
+	for name, value := range d.vars {
+		b.SetBufferVar(buf, name, value)
+	}
 	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> <CR> :<C-U>call rpcrequest(%d, 'doc.onJump', %d, line('.'), col('.'))<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> <C-T> :<C-U>call rpcrequest(%d, 'doc.onBack')<CR>", m.nvim.ChannelID()))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> gY :<C-U>call rpcrequest(%d, 'doc.onYankCode', %d, line('.'), v:register)<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> gX :<C-U>call rpcrequest(%d, 'doc.onOpenCode', %d, line('.'))<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> ]] :<C-U>call rpcrequest(%d, 'doc.onNextDecl', %d, line('.'), col('.'))<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> [[ :<C-U>call rpcrequest(%d, 'doc.onPrevDecl', %d, line('.'), col('.'))<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("nnoremap <buffer> <silent> gF :<C-U>call rpcrequest(%d, 'doc.onOpenSource', %d, line('.'), col('.'))<CR>", m.nvim.ChannelID(), int(buf)))
+	b.Command(fmt.Sprintf("execute 'nnoremap <buffer> <silent> ' . get(g:, 'vigor_up_key', '-') . ' :<C-U>call rpcrequest(%d, ''doc.onUp'', %d)<CR>'", m.nvim.ChannelID(), int(buf)))
 	if err := b.Execute(); err != nil {
 		return err
 	}
@@ -367,6 +1030,7 @@ func (m *Manager) Display(d *Doc, buf nvim.Buffer) error {
 	for i, s := range d.data.strings {
 		log.Println(i, s)
 	}
+	d.data.setSources(d.anchors)
 	m.mu.Lock()
 	m.docs[int(buf)] = d.data
 	m.mu.Unlock()
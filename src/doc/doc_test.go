@@ -0,0 +1,269 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package doc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+var upURITests = []struct {
+	uri, up string
+	ok      bool
+}{
+	{"godoc://net/http", "godoc://net", true},
+	{"godoc://net", "godoc://", true},
+	{"godoc://", "", false},
+	{"godoc://net/http#Client", "godoc://net/http", true},
+	{"godoc:///net", "godoc://", true},
+	{"godoc://./testdata/multi", "godoc://./testdata", true},
+	{"", "", false},
+	{"/home/gary/x.go", "", false},
+}
+
+func TestUpURI(t *testing.T) {
+	for _, tt := range upURITests {
+		up, ok := upURI(tt.uri)
+		if up != tt.up || ok != tt.ok {
+			t.Errorf("upURI(%q) = %q, %v, want %q, %v", tt.uri, up, ok, tt.up, tt.ok)
+		}
+	}
+}
+
+func TestBufferHighlights(t *testing.T) {
+	d := NewDoc()
+	d.WriteString("a ")
+	d.PushHighlight("One")
+	d.WriteString("bc\nde")
+	d.PopHighlight()
+	d.WriteString("\n")
+	hs := d.bufferHighlights()
+	want := []bufferHighlight{{"One", 0, 2, -1}, {"One", 1, 0, 2}}
+	if fmt.Sprint(hs) != fmt.Sprint(want) {
+		t.Errorf("highlights = %v, want %v", hs, want)
+	}
+}
+
+func TestFindCode(t *testing.T) {
+	d := NewDoc()
+	d.WriteString("func F()\n\n")
+	d.PushCode()
+	d.WriteString("    F()\n    G()\n")
+	d.PopCode("F()\nG()\n", "f_test.go", 10)
+	d.WriteString("\nfunc G()\n")
+	for line, want := range []string{1: "", 2: "", 3: "F()\nG()\n", 4: "F()\nG()\n", 5: "", 6: ""} {
+		code := ""
+		if c := d.data.findCode(line); c != nil {
+			code = c.code
+		}
+		if code != want {
+			t.Errorf("line %d: code = %q, want %q", line, code, want)
+		}
+	}
+}
+
+func TestLinkTarget(t *testing.T) {
+	d := NewDoc()
+	d.WriteLinkAnchor("Client", "godoc://net/http", "Client")
+	d.WriteString(" ")
+	d.WriteLink("Get", "/src/net/http/client.go", 10, 6)
+	d.WriteString(" ")
+	d.WriteLinkAnchor("http", "godoc://net/http", "")
+	want := []LinkTarget{
+		{Path: "godoc://net/http", Anchor: "Client"},
+		{Path: "/src/net/http/client.go", Line: 10, Column: 6},
+		{Path: "godoc://net/http"},
+	}
+	if len(d.data.links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(d.data.links), len(want))
+	}
+	for i, l := range d.data.links {
+		if target := d.data.linkTarget(l); target != want[i] {
+			t.Errorf("link %d target = %+v, want %+v", i, target, want[i])
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vigor-doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "x.go")
+	missing := filepath.Join(dir, "y.go")
+	if err := ioutil.WriteFile(fname, []byte("package x\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDoc()
+	d.Watch(fname, missing)
+	if d.data.changed() {
+		t.Fatal("changed before modification")
+	}
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fname, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if !d.data.changed() {
+		t.Error("modified file not detected")
+	}
+
+	d = NewDoc()
+	d.Watch(fname, missing)
+	if err := ioutil.WriteFile(missing, []byte("package x\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if !d.data.changed() {
+		t.Error("created file not detected")
+	}
+}
+
+func BenchmarkBufferHighlights(b *testing.B) {
+	d := NewDoc()
+	for i := 0; i < 10000; i++ {
+		d.PushHighlight("Comment")
+		fmt.Fprintf(d, "line %d\ncontinued", i)
+		d.PopHighlight()
+		d.WriteString("\n")
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.bufferHighlights()
+	}
+}
+
+func TestPruneHighlights(t *testing.T) {
+	m := &Manager{highlights: map[nvim.Window]*windowHighlight{
+		1000: {id: 1},
+		1001: {id: 2},
+		1002: {id: 3},
+	}}
+	m.pruneHighlights([]nvim.Window{1000, 1002, 1003})
+	if len(m.highlights) != 2 || m.highlights[1000] == nil || m.highlights[1002] == nil {
+		t.Errorf("highlights = %v, want windows 1000 and 1002", m.highlights)
+	}
+}
+
+func TestAddAnchor(t *testing.T) {
+	d := NewDoc()
+	d.AddAnchor("X")
+	d.WriteString("first\nsecond ")
+	d.AddAnchor("X")
+	d.AddAnchor("Y")
+	if line, col, _ := d.Anchor("X"); line != 1 || col != 1 {
+		t.Errorf("anchor X at %d:%d, want 1:1", line, col)
+	}
+	if line, col, _ := d.Anchor("Y"); line != 2 || col != 8 {
+		t.Errorf("anchor Y at %d:%d, want 2:8", line, col)
+	}
+	if _, _, ok := d.Anchor("Z"); ok {
+		t.Error("anchor Z found")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var h history
+	for i := 0; i < maxHistory+2; i++ {
+		h.push(location{buf: i % 3, line: i})
+	}
+	if len(h) != maxHistory || h[0].line != 2 {
+		t.Fatalf("len(h) = %d, h[0].line = %d, want %d, 2", len(h), h[0].line, maxHistory)
+	}
+	h.deleteBuffer(1)
+	for _, loc := range h {
+		if loc.buf == 1 {
+			t.Fatalf("location %v in deleted buffer", loc)
+		}
+	}
+	loc, ok := h.pop()
+	if !ok || loc.line != maxHistory+1 {
+		t.Errorf("pop() = %v, %v, want line %d", loc, ok, maxHistory+1)
+	}
+	h = nil
+	if _, ok := h.pop(); ok {
+		t.Error("pop() on empty history ok")
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	m := &Manager{docs: make(map[int]*data), highlights: make(map[nvim.Window]*windowHighlight)}
+	m.history.push(location{buf: 1, line: 2})
+	m.Clear()
+	if _, ok := m.history.pop(); ok {
+		t.Error("history not cleared")
+	}
+}
+
+func TestFindSource(t *testing.T) {
+	d := NewDoc()
+	d.WriteString("package http\n\n")
+	d.AddAnchor("Get")
+	d.WriteLink("Get", "/src/net/http/client.go", 10, 6)
+	d.WriteString("(url string)\n    Get issues a GET.\n\n")
+	d.WriteString("type ")
+	d.AddAnchor("Client")
+	d.WriteLink("Client", "/src/net/http/client.go", 20, 6)
+	d.WriteString(" struct {\n    ")
+	d.AddAnchor("Client.Jar")
+	d.WriteLink("Jar", "/src/net/http/client.go", 22, 2)
+	d.WriteString(" ")
+	d.WriteLink("CookieJar", "/src/net/http/jar.go", 5, 6)
+	d.WriteString("\n}\n")
+	d.data.setSources(d.anchors)
+	for line, want := range []int{1: 0, 2: 0, 3: 10, 4: 10, 5: 10, 6: 20, 7: 22, 8: 22} {
+		got := 0
+		if l := d.data.findSource(line); l != nil {
+			got = l.address.line()
+		}
+		if got != want {
+			t.Errorf("line %d: source line = %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestNextDecl(t *testing.T) {
+	d := NewDoc()
+	d.WriteString("package p\n\nfunc ")
+	d.AddDecl()
+	d.WriteString("F()\n\ntype ")
+	d.AddDecl()
+	d.WriteString("T int\n\nvar ")
+	d.AddDecl()
+	d.WriteString("a, ")
+	d.AddDecl()
+	d.WriteString("b int\n")
+	if got := d.Decls(); fmt.Sprint(got) != "[[3 6] [5 6] [7 5]]" {
+		t.Errorf("Decls() = %v, want [[3 6] [5 6] [7 5]]", got)
+	}
+	for _, tt := range []struct {
+		line, col int
+		backward  bool
+		want      position
+	}{
+		{1, 1, false, newPosition(3, 6)},
+		{3, 6, false, newPosition(5, 6)},
+		{5, 1, false, newPosition(5, 6)},
+		{7, 5, false, newPosition(3, 6)},
+		{7, 9, true, newPosition(7, 5)},
+		{5, 6, true, newPosition(3, 6)},
+		{3, 6, true, newPosition(7, 5)},
+		{1, 1, true, newPosition(7, 5)},
+	} {
+		got, ok := d.data.nextDecl(tt.line, tt.col, tt.backward)
+		if !ok || got != tt.want {
+			t.Errorf("nextDecl(%d, %d, %v) = %d:%d, want %d:%d", tt.line, tt.col, tt.backward, got.line(), got.column(), tt.want.line(), tt.want.column())
+		}
+	}
+	if _, ok := NewDoc().data.nextDecl(1, 1, false); ok {
+		t.Error("nextDecl on an empty page returned ok")
+	}
+}
@@ -7,6 +7,7 @@ package explore
 import (
 	"go/ast"
 	"go/build"
+	godoc "go/doc"
 	"go/parser"
 	"go/token"
 	"io"
@@ -15,11 +16,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/buildutil"
 )
 
-func completePackageArg(ctx *build.Context, cwd string, src io.Reader, arg string) (completions []string) {
+func completePackageArg(ctx *build.Context, cwd string, src io.Reader, arg string, scope string) (completions []string) {
 	switch {
 	case arg == ".":
 		completions = []string{"./", "../"}
@@ -46,7 +48,7 @@ func completePackageArg(ctx *build.Context, cwd string, src io.Reader, arg strin
 		}
 	case strings.HasPrefix(arg, "/"):
 		// Complete using full import path.
-		completions = completePackageArgByPath(ctx, cwd, arg)
+		completions = completePackageArgByPath(ctx, cwd, arg, scope)
 	default:
 		// Complete with package names imported in current file.
 		for n := range readImports(cwd, src) {
@@ -62,13 +64,24 @@ func completePackageArg(ctx *build.Context, cwd string, src io.Reader, arg strin
 	return completions
 }
 
-func resolvePackageSpec(ctx *build.Context, cwd string, src io.Reader, spec string) string {
+// resolvePackageSpec returns the import path for a package specification.
+// The line number in a file.go:line specification is ignored. A file outside
+// of GOPATH is resolved to the local import path of its directory. Relative
+// specifications are resolved relative to cwd or, if the package is
+// not found there, relative to bufDir, the directory of the current buffer.
+func resolvePackageSpec(ctx *build.Context, cwd, bufDir string, src io.Reader, spec string) string {
+	if fname, _, ok := fileLine(spec); ok {
+		spec = fname
+	}
 	if strings.HasSuffix(spec, ".go") {
 		d := path.Dir(spec)
 		if !buildutil.IsAbsPath(ctx, d) {
 			d = buildutil.JoinPath(ctx, cwd, d)
 		}
 		if bpkg, err := ctx.ImportDir(d, build.FindOnly); err == nil {
+			if build.IsLocalImport(bpkg.ImportPath) && bpkg.Dir != filepath.Clean(cwd) {
+				return relativeImportPath(cwd, bpkg.Dir)
+			}
 			return bpkg.ImportPath
 		}
 	}
@@ -77,6 +90,13 @@ func resolvePackageSpec(ctx *build.Context, cwd string, src io.Reader, spec stri
 	case strings.HasPrefix(spec, "."):
 		if bpkg, err := ctx.Import(spec, cwd, build.FindOnly); err == nil {
 			path = bpkg.ImportPath
+		} else if bufDir != "" {
+			if bpkg, err := ctx.Import(spec, bufDir, build.FindOnly); err == nil {
+				path = bpkg.ImportPath
+				if build.IsLocalImport(path) {
+					path = relativeImportPath(cwd, bpkg.Dir)
+				}
+			}
 		}
 	case strings.HasPrefix(spec, "/"):
 		path = spec[1:]
@@ -88,10 +108,47 @@ func resolvePackageSpec(ctx *build.Context, cwd string, src io.Reader, spec stri
 	return strings.TrimSuffix(path, "/")
 }
 
-func completePackageArgByPath(ctx *build.Context, cwd, arg string) []string {
+// relativeImportPath returns the local import path for dir relative to cwd.
+// Buffer names use local import paths relative to cwd.
+func relativeImportPath(cwd, dir string) string {
+	rel, err := filepath.Rel(cwd, dir)
+	if err != nil {
+		return dir
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "..") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// completionOptions specifies how command line arguments are completed.
+type completionOptions struct {
+	// Fuzzy enables matching of symbols containing the typed characters in
+	// order.
+	Fuzzy bool `eval:"get(g:, 'vigor_fuzzy_completion', 0)"`
+
+	// Scope limits the packages completed for import paths. See the scope
+	// constants.
+	Scope string `eval:"get(g:, 'vigor_completion_scope', 'all')"`
+}
+
+// Scopes for completing import paths.
+const (
+	scopeAll        = "all"         // All packages.
+	scopeStd        = "std"         // Standard packages.
+	scopeThirdParty = "third-party" // Packages in GOPATH.
+	scopeModule     = "module"      // Packages in the module containing cwd.
+)
+
+func completePackageArgByPath(ctx *build.Context, cwd, arg string, scope string) []string {
 	var completions []string
 	dir, name := path.Split(arg[1:])
+	goroot, _ := srcRoot(ctx, ctx.GOROOT)
 	for _, root := range ctx.SrcDirs() {
+		if (root == goroot) != (scope == scopeStd) && scope != scopeAll {
+			continue
+		}
 		if sub, ok := hasSubDir(ctx, root, cwd); ok {
 			for {
 				completions = addCompletions(completions, ctx, buildutil.JoinPath(ctx, root, sub, "vendor"), dir, name)
@@ -104,9 +161,25 @@ func completePackageArgByPath(ctx *build.Context, cwd, arg string) []string {
 		}
 		completions = addCompletions(completions, ctx, root, dir, name)
 	}
+	if modPath, _ := moduleForDir(cwd); scope == scopeModule && modPath != "" {
+		completions = inModule(completions, modPath)
+	}
 	return completions
 }
 
+// inModule returns the import path completions leading to or inside of the
+// module modPath.
+func inModule(completions []string, modPath string) []string {
+	modPath += "/"
+	var result []string
+	for _, c := range completions {
+		if p := c[1:]; strings.HasPrefix(p, modPath) || strings.HasPrefix(modPath, p) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
 func addCompletions(completions []string, ctx *build.Context, root, dir, name string) []string {
 	fis, err := buildutil.ReadDir(ctx, buildutil.JoinPath(ctx, root, dir))
 	if err != nil {
@@ -139,8 +212,12 @@ func hasSubDir(ctx *build.Context, root, dir string) (rel string, ok bool) {
 	return filepath.ToSlash(dir[len(root):]), true
 }
 
-func completeSymMethodArg(ctx *build.Context, importPath, symMethod string) (completions []string) {
-	pkg, err := loadPackage(ctx, importPath, "", loadPackageDoc)
+// completeSymMethodArg completes the symbol argument symMethod. Symbols with
+// symMethod as a case-insensitive prefix are completed. If fuzzy is true,
+// then symbols containing the characters of symMethod in order are also
+// completed, ranked after the prefix matches.
+func completeSymMethodArg(ctx *build.Context, importPath, symMethod string, fuzzy bool) []string {
+	pkg, err := loadPackage(ctx, importPath, "", loadPackageDoc|loadPackageAllMethods)
 	if err != nil {
 		return []string{symMethod}
 	}
@@ -153,40 +230,280 @@ func completeSymMethodArg(ctx *build.Context, importPath, symMethod string) (com
 		method = symMethod[i+1:]
 	}
 
+	var matches []*scoredCompletion
+	add := func(name, arg, completion string) {
+		if score, ok := matchScore(strings.ToLower(name), arg, fuzzy); ok {
+			matches = append(matches, &scoredCompletion{completion, score})
+		}
+	}
+
 	if method != "" {
 		for _, d := range pkg.GoDoc.Types {
 			if strings.ToLower(d.Name) == sym {
-				for _, m := range d.Methods {
-					if strings.HasPrefix(strings.ToLower(m.Name), method) {
-						completions = append(completions, d.Name+"."+m.Name)
-					}
+				for _, n := range typeMethods(ctx, pkg, d) {
+					add(n, method, d.Name+"."+n)
+				}
+				for _, n := range typeFields(d) {
+					add(n, method, d.Name+"."+n)
 				}
 			}
 		}
 	} else {
-		untangleDoc(pkg.GoDoc)
-		add := func(n string) {
-			if strings.HasPrefix(strings.ToLower(n), sym) {
-				completions = append(completions, n)
-			}
-		}
-		for _, d := range append(pkg.GoDoc.Consts, pkg.GoDoc.Vars...) {
+		doc := untangleDoc(pkg.GoDoc)
+		for _, d := range append(doc.Consts, doc.Vars...) {
 			for _, n := range d.Names {
-				add(n)
+				add(n, sym, n)
 			}
 		}
-		for _, d := range pkg.GoDoc.Funcs {
-			add(d.Name)
+		for _, d := range doc.Funcs {
+			add(d.Name, sym, d.Name)
 		}
 		for _, d := range pkg.GoDoc.Types {
-			add(d.Name + ".")
+			add(d.Name, sym, d.Name+".")
 		}
 	}
 
-	sort.Strings(completions)
+	sort.Sort(byScore(matches))
+	var completions []string
+	for _, m := range matches {
+		completions = append(completions, m.completion)
+	}
+	return completions
+}
+
+type scoredCompletion struct {
+	completion string
+	score      int
+}
+
+type byScore []*scoredCompletion
+
+func (s byScore) Len() int      { return len(s) }
+func (s byScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool {
+	if s[i].score != s[j].score {
+		return s[i].score < s[j].score
+	}
+	return s[i].completion < s[j].completion
+}
+
+// matchScore returns the score for matching arg against name. Lower scores
+// are better. A prefix match has score zero. If fuzzy is true, then a
+// subsequence match scores one plus the number of characters in name skipped
+// by the match.
+func matchScore(name, arg string, fuzzy bool) (int, bool) {
+	if strings.HasPrefix(name, arg) {
+		return 0, true
+	}
+	if !fuzzy {
+		return 0, false
+	}
+	skipped := 0
+	i := 0
+	for _, c := range arg {
+		j := strings.IndexRune(name[i:], c)
+		if j < 0 {
+			return 0, false
+		}
+		skipped += j
+		i += j + utf8.RuneLen(c)
+	}
+	return 1 + skipped, true
+}
+
+// embeddedDoc is a type found by typeMethods and the package declaring the
+// type.
+type embeddedDoc struct {
+	pkg *pkg
+	t   *godoc.Type
+}
+
+// typeMethods returns the names of the methods of type t in pkg including
+// the methods of an interface type and the methods promoted from embedded
+// types. The package is loaded with loadPackageAllMethods, so t.Methods
+// includes the methods promoted from types in the same package. The types
+// embedded from other packages are loaded to find their methods. Each name
+// is returned once at the shallowest embedding depth.
+func typeMethods(ctx *build.Context, pkg *pkg, t *godoc.Type) []string {
+	var names []string
+	seen := map[string]bool{}
+	visited := map[*godoc.Type]bool{}
+	level := []embeddedDoc{{pkg, t}}
+	for len(level) > 0 {
+		var next []embeddedDoc
+		for _, e := range level {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+			methods, types := typeMembers(e.t)
+			for _, m := range e.t.Methods {
+				methods = append(methods, m.Name)
+			}
+			for _, name := range methods {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			for _, x := range types {
+				if p, et := embeddedType(ctx, e.pkg, x); et != nil {
+					next = append(next, embeddedDoc{p, et})
+				}
+			}
+		}
+		level = next
+	}
+	return names
+}
+
+// typeMembers returns the names of the exported methods declared in the
+// interface type t and the types embedded in the struct or interface type t.
+func typeMembers(t *godoc.Type) ([]string, []ast.Expr) {
+	var methods []string
+	var types []ast.Expr
+	for _, spec := range t.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != t.Name {
+			continue
+		}
+		var fields *ast.FieldList
+		switch typ := ts.Type.(type) {
+		case *ast.StructType:
+			fields = typ.Fields
+		case *ast.InterfaceType:
+			fields = typ.Methods
+		default:
+			continue
+		}
+		for _, f := range fields.List {
+			if len(f.Names) == 0 {
+				types = append(types, f.Type)
+				continue
+			}
+			if _, ok := f.Type.(*ast.FuncType); ok {
+				for _, n := range f.Names {
+					if n.IsExported() {
+						methods = append(methods, n.Name)
+					}
+				}
+			}
+		}
+	}
+	return methods, types
+}
+
+// embeddedType returns the package and documentation of the type embedded
+// as x in a type in pkg or nil if the type is not found.
+func embeddedType(ctx *build.Context, pkg *pkg, x ast.Expr) (*pkg, *godoc.Type) {
+	if star, ok := x.(*ast.StarExpr); ok {
+		x = star.X
+	}
+	name := ""
+	switch x := x.(type) {
+	case *ast.Ident:
+		name = x.Name
+	case *ast.SelectorExpr:
+		id, ok := x.X.(*ast.Ident)
+		if !ok || id.Obj == nil || id.Obj.Kind != ast.Pkg {
+			return nil, nil
+		}
+		spec, ok := id.Obj.Decl.(*ast.ImportSpec)
+		if !ok {
+			return nil, nil
+		}
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, nil
+		}
+		pkg, err = loadPackage(ctx, importPath, pkg.Build.Dir, loadPackageDoc|loadPackageAllMethods)
+		if err != nil || pkg.GoDoc == nil {
+			return nil, nil
+		}
+		name = x.Sel.Name
+	}
+	for _, t := range pkg.GoDoc.Types {
+		if t.Name == name {
+			return pkg, t
+		}
+	}
+	return nil, nil
+}
+
+// typeFields returns the names of the exported fields in a struct type.
+func typeFields(t *godoc.Type) []string {
+	var names []string
+	for _, spec := range t.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != t.Name {
+			continue
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, f := range st.Fields.List {
+			for _, n := range f.Names {
+				if n.IsExported() {
+					names = append(names, n.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// completeMemberArg completes the method or field argument following the
+// type argument typ. The completions do not include the type name.
+func completeMemberArg(ctx *build.Context, importPath, typ, member string, fuzzy bool) []string {
+	var completions []string
+	for _, c := range completeSymMethodArg(ctx, importPath, typ+"."+member, fuzzy) {
+		if i := strings.Index(c, "."); i >= 0 {
+			completions = append(completions, c[i+1:])
+		}
+	}
+	if len(completions) == 0 {
+		completions = []string{member}
+	}
 	return completions
 }
 
+// matchPackages returns the import paths matching the pattern. The pattern
+// uses "..." as a wildcard as in the go command.
+func matchPackages(ctx *build.Context, pattern string) []string {
+	var paths []string
+	for p := range buildutil.ExpandPatterns(ctx, []string{pattern}) {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// matchSymbols returns the symbols in the package matching symMethod. If the
+// package declares symMethod, then only symMethod is returned. Otherwise,
+// the symbols with symMethod as a case-insensitive prefix are returned. A
+// const. or var. prefix on symMethod is kept on the returned symbols.
+func matchSymbols(ctx *build.Context, importPath, symMethod string) []string {
+	for _, prefix := range []string{"const.", "var."} {
+		if strings.HasPrefix(symMethod, prefix) {
+			syms := matchSymbols(ctx, importPath, symMethod[len(prefix):])
+			for i := range syms {
+				syms[i] = prefix + syms[i]
+			}
+			return syms
+		}
+	}
+	var syms []string
+	for _, c := range completeSymMethodArg(ctx, importPath, symMethod, false) {
+		c = strings.TrimSuffix(c, ".")
+		if c == symMethod {
+			return []string{c}
+		}
+		syms = append(syms, c)
+	}
+	return syms
+}
+
 // readImports returns the imports from the Go source file src. Errors are
 // silently ignored.
 func readImports(cwd string, src io.Reader) map[string]string {
@@ -0,0 +1,200 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+var matchSymbolsTests = []struct {
+	importPath, sym string
+	want            []string
+}{
+	{"strings", "Index", []string{"Index"}},
+	{"strings", "indexb", []string{"IndexByte"}},
+	{"strings", "Reader.Read", []string{"Reader.Read"}},
+	{"strings", "Reader.readr", []string{"Reader.ReadRune"}},
+	{"strings", "NoSuchSymbol", nil},
+	{"net/http", "Client.Timeout", []string{"Client.Timeout"}},
+	{"io", "var.EOF", []string{"var.EOF"}},
+	{"io", "const.SeekStart", []string{"const.SeekStart"}},
+}
+
+func TestMatchSymbols(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	for _, tt := range matchSymbolsTests {
+		got := matchSymbols(&ctx.Build, tt.importPath, tt.sym)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchSymbols(%q, %q) = %v, want %v", tt.importPath, tt.sym, got, tt.want)
+		}
+	}
+}
+
+var completeMemberArgTests = []struct {
+	importPath, typ, member string
+	want                    []string
+}{
+	{"net/http", "Client", "t", []string{"Timeout", "Transport"}},
+	{"net/http", "client", "close", []string{"CloseIdleConnections"}},
+	{"net/http", "Client", "nosuchmember", []string{"nosuchmember"}},
+}
+
+func TestCompleteMemberArg(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	for _, tt := range completeMemberArgTests {
+		got := completeMemberArg(&ctx.Build, tt.importPath, tt.typ, tt.member, false)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeMemberArg(%q, %q, %q) = %v, want %v", tt.importPath, tt.typ, tt.member, got, tt.want)
+		}
+	}
+}
+
+var completeSymMethodArgTests = []struct {
+	importPath, sym string
+	fuzzy           bool
+	want            []string
+}{
+	{"strings", "Rdr", false, nil},
+	{"strings", "Rdr", true, []string{"Reader.", "NewReader"}},
+	{"strings", "Reader.rrn", true, []string{"Reader.ReadRune", "Reader.UnreadRune"}},
+	{"strings", "newr", true, []string{"NewReader", "NewReplacer"}},
+}
+
+func TestCompleteSymMethodArg(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	for _, tt := range completeSymMethodArgTests {
+		got := completeSymMethodArg(&ctx.Build, tt.importPath, tt.sym, tt.fuzzy)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeSymMethodArg(%q, %q, %v) = %v, want %v", tt.importPath, tt.sym, tt.fuzzy, got, tt.want)
+		}
+	}
+}
+
+var matchScoreTests = []struct {
+	name, arg string
+	fuzzy     bool
+	score     int
+	ok        bool
+}{
+	{"reader", "rea", false, 0, true},
+	{"reader", "rdr", false, 0, false},
+	{"reader", "rdr", true, 4, true},
+	{"reader", "rea", true, 0, true},
+	{"reader", "rx", true, 0, false},
+}
+
+func TestMatchScore(t *testing.T) {
+	for _, tt := range matchScoreTests {
+		score, ok := matchScore(tt.name, tt.arg, tt.fuzzy)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("matchScore(%q, %q, %v) = %d, %v, want %d, %v", tt.name, tt.arg, tt.fuzzy, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+var resolvePackageSpecTests = []struct {
+	cwd, bufDir, spec, wantDir string
+}{
+	{"testdata", "", "./multi", "testdata/multi"},
+	{"testdata/replace", "testdata", "./multi", "testdata/multi"},
+	{"testdata/replace", "testdata", "./replace/dep", "testdata/replace/dep"},
+	{"testdata/replace", "testdata", "./dep", "testdata/replace/dep"},
+	{"testdata", "", "multi/main.go", "testdata/multi"},
+	{"testdata", "", "multi/main.go:3", "testdata/multi"},
+	{"testdata/multi", "", "main.go", "testdata/multi"},
+}
+
+func TestResolvePackageSpec(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	for _, tt := range resolvePackageSpecTests {
+		cwd, _ := filepath.Abs(tt.cwd)
+		bufDir := ""
+		if tt.bufDir != "" {
+			bufDir, _ = filepath.Abs(tt.bufDir)
+		}
+		wantDir, _ := filepath.Abs(tt.wantDir)
+		got := resolvePackageSpec(&ctx.Build, cwd, bufDir, nil, tt.spec)
+		bpkg, err := ctx.Build.Import(got, cwd, build.FindOnly)
+		if err != nil || bpkg.Dir != wantDir {
+			t.Errorf("resolvePackageSpec(%q, %q, %q) = %q, want path for %s", tt.cwd, tt.bufDir, tt.spec, got, tt.wantDir)
+		}
+	}
+}
+
+var completePackageArgByPathTests = []struct {
+	arg, scope string
+	want       []string
+}{
+	{"/net/ht", scopeAll, []string{"/net/http/"}},
+	{"/net/ht", scopeStd, []string{"/net/http/"}},
+	{"/net/ht", scopeThirdParty, nil},
+	{"/example.com/", scopeStd, nil},
+	{"/example.com/", scopeThirdParty, []string{"/example.com/mod/", "/example.com/other/"}},
+	{"/", scopeModule, []string{"/example.com/"}},
+	{"/example.com/", scopeModule, []string{"/example.com/mod/"}},
+	{"/example.com/mod/", scopeModule, []string{"/example.com/mod/a/", "/example.com/mod/b/"}},
+}
+
+func TestCompletePackageArgByPath(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "vigor-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	modDir := filepath.Join(gopath, "src", "example.com", "mod")
+	for _, dir := range []string{"a", "b", "../other"} {
+		if err := os.MkdirAll(filepath.Join(modDir, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(modDir, "go.mod"), []byte("module example.com/mod\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Get(&context.Env{}).Build
+	ctx.GOPATH = gopath
+	for _, tt := range completePackageArgByPathTests {
+		got := completePackageArgByPath(&ctx, modDir, tt.arg, tt.scope)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completePackageArgByPath(%q, %q) = %v, want %v", tt.arg, tt.scope, got, tt.want)
+		}
+	}
+}
+
+var typeMethodsTests = []struct {
+	typ  string
+	want []string
+}{
+	{"Outer", []string{"Goodbye", "Hello", "Own"}},
+	{"Buffered", []string{"Goodbye", "Hello", "Len", "Read", "ReadAt", "ReadByte", "ReadRune", "Reset", "Seek", "Size", "UnreadByte", "UnreadRune", "WriteTo"}},
+	{"ReadHello", []string{"Hello", "Read", "Close"}},
+}
+
+func TestTypeMethods(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/embed", cwd, loadPackageDoc|loadPackageAllMethods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range typeMethodsTests {
+		var got []string
+		for _, d := range pkg.GoDoc.Types {
+			if d.Name == tt.typ {
+				got = typeMethods(&ctx.Build, pkg, d)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("typeMethods(%s) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/garyburd/vigor/src/doc"
+)
+
+// docBufferPaths returns the sorted import paths of the documentation buffer
+// names. Names of other buffers are skipped. The root page has an empty
+// import path.
+func docBufferPaths(names []string) []string {
+	var paths []string
+	for _, name := range names {
+		if strings.HasPrefix(name, bufNamePrefix) {
+			paths = append(paths, strings.TrimPrefix(name, bufNamePrefix))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// printBuffers prints a list of links to the documentation buffers with the
+// given names.
+func printBuffers(names []string) *doc.Doc {
+	d := doc.NewDoc()
+	d.PushHighlight(headerGroup)
+	d.WriteString("DOCUMENTATION BUFFERS")
+	d.PopHighlight()
+	d.WriteString("\n\n")
+	paths := docBufferPaths(names)
+	if len(paths) == 0 {
+		d.WriteString(textIndent + "No documentation buffers.\n")
+	}
+	for _, path := range paths {
+		text := path
+		if text == "" {
+			text = "(packages)"
+		}
+		d.WriteString(textIndent)
+		d.WriteLinkAnchor(text, bufNamePrefix+path, "")
+		d.WriteString("\n")
+	}
+	return d
+}
+
+// bufferLabel returns the label for a buffer with the given name. The label
+// for a documentation buffer is prefix followed by the import path. Names of
+// other buffers are returned unchanged.
+func bufferLabel(name, prefix string) string {
+	if !strings.HasPrefix(name, bufNamePrefix) {
+		return name
+	}
+	importPath := strings.TrimPrefix(name, bufNamePrefix)
+	tags := ""
+	if i := strings.Index(importPath, tagsQuery); i >= 0 {
+		tags = " (tags: " + importPath[i+len(tagsQuery):] + ")"
+		importPath = importPath[:i]
+	}
+	if importPath == "" {
+		importPath = "packages"
+	}
+	return prefix + importPath + tags
+}
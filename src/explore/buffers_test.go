@@ -0,0 +1,43 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocBufferPaths(t *testing.T) {
+	names := []string{
+		bufNamePrefix + "net/http",
+		"",
+		"/home/gary/main.go",
+		bufNamePrefix,
+		bufNamePrefix + "fmt",
+	}
+	want := []string{"", "fmt", "net/http"}
+	if paths := docBufferPaths(names); !reflect.DeepEqual(paths, want) {
+		t.Errorf("docBufferPaths() = %q, want %q", paths, want)
+	}
+}
+
+var bufferLabelTests = []struct {
+	name, prefix, want string
+}{
+	{bufNamePrefix + "net/http", "[godoc] ", "[godoc] net/http"},
+	{bufNamePrefix + "fmt", "", "fmt"},
+	{bufNamePrefix, "[godoc] ", "[godoc] packages"},
+	{bufNamePrefix + "example.com/tags?tags=integration,linux", "[godoc] ", "[godoc] example.com/tags (tags: integration,linux)"},
+	{"/home/gary/main.go", "[godoc] ", "/home/gary/main.go"},
+	{"", "[godoc] ", ""},
+}
+
+func TestBufferLabel(t *testing.T) {
+	for _, tt := range bufferLabelTests {
+		if label := bufferLabel(tt.name, tt.prefix); label != tt.want {
+			t.Errorf("bufferLabel(%q, %q) = %q, want %q", tt.name, tt.prefix, label, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/garyburd/vigor/src/doc"
+)
+
+// printBuildInfo prints the modules that the binary with build info was
+// built from. The modules are linked to the documentation for the module
+// root package at the version recorded in the binary. The module sources are
+// found in the module caches of the GOPATH roots. Modules that are not in a
+// module cache are printed without a link.
+func printBuildInfo(info *debug.BuildInfo, name string, roots []string, cwd string) *doc.Doc {
+	d := doc.NewDoc()
+	d.PushHighlight(headerGroup)
+	d.WriteString("BINARY")
+	d.PopHighlight()
+	d.WriteString("\n\n")
+	fmt.Fprintf(d, "%s%s\n", textIndent, name)
+	fmt.Fprintf(d, "%sBuilt with %s from package %s.\n", textIndent, info.GoVersion, info.Path)
+
+	writeModule := func(m *debug.Module) {
+		d.WriteString(textIndent)
+		text := m.Path + " " + m.Version
+		if m.Replace != nil {
+			text += " => " + m.Replace.Path + " " + m.Replace.Version
+			m = m.Replace
+		}
+		dir, ok := "", false
+		if m.Version != "" && m.Version != "(devel)" {
+			for _, root := range roots {
+				if dir, ok = moduleVersionDir(filepath.Join(root, "pkg", "mod"), m.Path, m.Version); ok {
+					break
+				}
+			}
+		}
+		if ok {
+			d.WriteLinkAnchor(text, bufNamePrefix+relativeImportPath(cwd, dir), "")
+		} else {
+			d.WriteString(text)
+			d.PushHighlight(commentGroup)
+			d.WriteString(" (not in module cache)")
+			d.PopHighlight()
+		}
+		d.WriteString("\n")
+	}
+
+	d.WriteString("\n")
+	d.PushHighlight(headerGroup)
+	d.WriteString("MAIN MODULE")
+	d.PopHighlight()
+	d.WriteString("\n\n")
+	writeModule(&info.Main)
+
+	d.WriteString("\n")
+	d.PushHighlight(headerGroup)
+	d.WriteString("DEPENDENCIES")
+	d.PopHighlight()
+	d.WriteString("\n\n")
+	if len(info.Deps) == 0 {
+		d.WriteString(textIndent + "No dependencies.\n")
+	}
+	for _, m := range info.Deps {
+		writeModule(m)
+	}
+	return d
+}
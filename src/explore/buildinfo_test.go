@@ -0,0 +1,55 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestPrintBuildInfo(t *testing.T) {
+	root, err := ioutil.TempDir("", "vigor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	cache, _ := filepath.Abs("testdata/mod")
+	if err := os.Mkdir(filepath.Join(root, "pkg"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(cache, filepath.Join(root, "pkg", "mod")); err != nil {
+		t.Fatal(err)
+	}
+
+	info := &debug.BuildInfo{
+		GoVersion: "go1.21.0",
+		Path:      "example.com/cmd/hello",
+		Main:      debug.Module{Path: "example.com/cmd", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "example.com/cached", Version: "v1.2.3"},
+			{Path: "example.com/Upper", Version: "v0.1.0"},
+			{Path: "example.com/missing", Version: "v1.0.0"},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/cached", Version: "v1.10.0"}},
+		},
+	}
+	d := printBuildInfo(info, "hello", []string{root}, root)
+	got := string(d.Bytes())
+	for _, want := range []string{
+		"Built with go1.21.0 from package example.com/cmd/hello.",
+		"    example.com/cmd (devel) (not in module cache)\n",
+		"    example.com/cached v1.2.3\n",
+		"    example.com/Upper v0.1.0\n",
+		"    example.com/missing v1.0.0 (not in module cache)\n",
+		"    example.com/old v1.0.0 => example.com/cached v1.10.0\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("page does not contain %q\n%s", want, got)
+		}
+	}
+}
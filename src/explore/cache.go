@@ -0,0 +1,119 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"container/list"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxCachedPackages is the number of loaded packages kept in the package
+// cache.
+const maxCachedPackages = 32
+
+// cachedPackage is an entry in the package cache.
+type cachedPackage struct {
+	key loadKey
+	pkg *pkg
+
+	// modTimes maps the package directory and the package files to their
+	// modification times when the package was loaded.
+	modTimes map[string]time.Time
+}
+
+// packageCache is a least recently used cache of loaded packages. The
+// packages in the cache are shared by the callers of loadPackage and must
+// not be modified.
+var packageCache = struct {
+	mu      sync.Mutex
+	lru     *list.List // of *cachedPackage, most recently used first
+	entries map[loadKey]*list.Element
+}{
+	lru:     list.New(),
+	entries: make(map[loadKey]*list.Element),
+}
+
+// getCachedPackage returns the cached package for key or nil if the package
+// is not cached or a file in the package changed since the package was
+// loaded.
+func getCachedPackage(key loadKey) *pkg {
+	packageCache.mu.Lock()
+	defer packageCache.mu.Unlock()
+	e := packageCache.entries[key]
+	if e == nil {
+		return nil
+	}
+	c := e.Value.(*cachedPackage)
+	if modTimesChanged(c.modTimes) {
+		packageCache.lru.Remove(e)
+		delete(packageCache.entries, key)
+		return nil
+	}
+	packageCache.lru.MoveToFront(e)
+	return c.pkg
+}
+
+// modTimesChanged returns true if a file in m was removed or modified since
+// the modification times in m were recorded by packageModTimes.
+func modTimesChanged(m map[string]time.Time) bool {
+	for fname, t := range m {
+		if fi, err := os.Stat(fname); err != nil || !fi.ModTime().Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// putCachedPackage adds pkg loaded relative to srcDir to the cache. The
+// least recently used package is dropped when the cache is full.
+func putCachedPackage(key loadKey, pkg *pkg, srcDir string) {
+	c := &cachedPackage{key: key, pkg: pkg, modTimes: packageModTimes(pkg.Build, srcDir)}
+	packageCache.mu.Lock()
+	defer packageCache.mu.Unlock()
+	if e := packageCache.entries[key]; e != nil {
+		packageCache.lru.Remove(e)
+	}
+	packageCache.entries[key] = packageCache.lru.PushFront(c)
+	for packageCache.lru.Len() > maxCachedPackages {
+		e := packageCache.lru.Back()
+		packageCache.lru.Remove(e)
+		delete(packageCache.entries, e.Value.(*cachedPackage).key)
+	}
+}
+
+// clearPackageCache removes all packages from the cache.
+func clearPackageCache() {
+	packageCache.mu.Lock()
+	packageCache.lru.Init()
+	packageCache.entries = make(map[loadKey]*list.Element)
+	packageCache.mu.Unlock()
+}
+
+// packageModTimes returns the modification times of the package directory,
+// the files read by loadPackage and the go.mod file for srcDir. The time of
+// the directory changes when files are added to or removed from the
+// directory. The go.mod file can replace the package directory.
+func packageModTimes(bpkg *build.Package, srcDir string) map[string]time.Time {
+	m := make(map[string]time.Time)
+	add := func(fname string) {
+		if fi, err := os.Stat(fname); err == nil {
+			m[fname] = fi.ModTime()
+		}
+	}
+	add(bpkg.Dir)
+	if fname := findModFile(srcDir); fname != "" {
+		add(fname)
+	}
+	for _, names := range [][]string{bpkg.GoFiles, bpkg.CgoFiles, bpkg.TestGoFiles, bpkg.XTestGoFiles} {
+		for _, name := range names {
+			add(filepath.Join(bpkg.Dir, name))
+		}
+	}
+	return m
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestPackageCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vigor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "p", "p.go")
+	if err := os.Mkdir(filepath.Dir(fname), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fname, []byte("package p\n\nfunc A() {}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Get(&context.Env{})
+	pkg1, err := loadPackage(&ctx.Build, "./p", dir, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg2, err := loadPackage(&ctx.Build, "./p", dir, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg1 != pkg2 {
+		t.Error("package not cached")
+	}
+	if pkg3, _ := loadPackage(&ctx.Build, "./p", dir, loadPackageDoc|loadPackageUnexported); pkg3 == pkg1 {
+		t.Error("package cached for different flags")
+	}
+
+	if err := ioutil.WriteFile(fname, []byte("package p\n\nfunc B() {}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	// Set a modification time that differs from the first write on file
+	// systems with coarse timestamps.
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fname, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	pkg4, err := loadPackage(&ctx.Build, "./p", dir, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg4 == pkg1 || len(pkg4.GoDoc.Funcs) != 1 || pkg4.GoDoc.Funcs[0].Name != "B" {
+		t.Error("changed package not loaded again")
+	}
+
+	clearPackageCache()
+	if pkg5, _ := loadPackage(&ctx.Build, "./p", dir, loadPackageDoc); pkg5 == pkg4 {
+		t.Error("package cached after clear")
+	}
+}
+
+func TestPackageCacheEviction(t *testing.T) {
+	defer clearPackageCache()
+	clearPackageCache()
+	var ctx build.Context
+	var keys []loadKey
+	for i := 0; i < maxCachedPackages+1; i++ {
+		keys = append(keys, newLoadKey(&ctx, "p", "", i))
+	}
+	putCachedPackage(keys[0], &pkg{Build: &build.Package{}}, "")
+	putCachedPackage(keys[1], &pkg{Build: &build.Package{}}, "")
+	// Use the first package so that the second is the least recently used.
+	getCachedPackage(keys[0])
+	for _, key := range keys[2:] {
+		putCachedPackage(key, &pkg{Build: &build.Package{}}, "")
+	}
+	if getCachedPackage(keys[0]) == nil {
+		t.Error("recently used package evicted")
+	}
+	if getCachedPackage(keys[1]) != nil {
+		t.Error("least recently used package not evicted")
+	}
+}
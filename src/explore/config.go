@@ -0,0 +1,76 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+// configEnviron are the environment variables shown by :Vigorconfig.
+var configEnviron = []string{"GOFLAGS", "GOPROXY", "GOSUMDB", "GO111MODULE"}
+
+// configText returns a description of the effective configuration.
+func configText(ctx *context.Context, options *docOptions, completion *completionOptions, upKey string, docBuffers int) []byte {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ENVIRONMENT\n\n")
+	fmt.Fprintf(w, "%sGOROOT\t%s\n", textIndent, ctx.Build.GOROOT)
+	fmt.Fprintf(w, "%sGOPATH\t%s\n", textIndent, ctx.Build.GOPATH)
+	fmt.Fprintf(w, "%sGOOS\t%s\n", textIndent, ctx.Build.GOOS)
+	fmt.Fprintf(w, "%sGOARCH\t%s\n", textIndent, ctx.Build.GOARCH)
+	fmt.Fprintf(w, "%sCgoEnabled\t%v\n", textIndent, ctx.Build.CgoEnabled)
+	fmt.Fprintf(w, "%sBuildTags\t%s\n", textIndent, strings.Join(ctx.Build.BuildTags, ","))
+	for _, name := range configEnviron {
+		value := ""
+		for _, e := range ctx.Environ {
+			if strings.HasPrefix(e, name+"=") {
+				value = e[len(name)+1:]
+			}
+		}
+		fmt.Fprintf(w, "%s%s\t%s\n", textIndent, name, value)
+	}
+
+	fmt.Fprintf(w, "\nDOCUMENTATION\n\n")
+	writeConfigFields(w, options)
+	fmt.Fprintf(w, "%sUpKey\t%s\n", textIndent, upKey)
+
+	fmt.Fprintf(w, "\nCOMPLETION\n\n")
+	writeConfigFields(w, completion)
+
+	fmt.Fprintf(w, "\nHIGHLIGHTS\n\n")
+	for _, h := range []struct{ name, group string }{
+		{"Header", headerGroup},
+		{"Comment", commentGroup},
+		{"Declaration", declGroup},
+		{"String", stringGroup},
+		{"Number", numberGroup},
+		{"Deprecated", deprecatedGroup},
+		{"Badge", badgeGroup},
+	} {
+		fmt.Fprintf(w, "%s%s\t%s\n", textIndent, h.name, h.group)
+	}
+
+	fmt.Fprintf(w, "\nSTATE\n\n")
+	fmt.Fprintf(w, "%sDocumentation buffers\t%d\n", textIndent, docBuffers)
+
+	w.Flush()
+	return buf.Bytes()
+}
+
+// writeConfigFields writes the name and value of the fields in the struct
+// pointed to by v.
+func writeConfigFields(w *tabwriter.Writer, v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		fmt.Fprintf(w, "%s%s\t%v\n", textIndent, rv.Type().Field(i).Name, rv.Field(i).Interface())
+	}
+}
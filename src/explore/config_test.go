@@ -0,0 +1,29 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestConfigText(t *testing.T) {
+	ctx := context.Get(&context.Env{Offline: true})
+	p := configText(ctx, &docOptions{Detail: detailExamples}, &completionOptions{Scope: scopeStd}, "-", 3)
+	for _, pat := range []string{
+		`(?m)^    GOPROXY +off$`,
+		`(?m)^    Detail +2$`,
+		`(?m)^    Scope +std$`,
+		`(?m)^    Declaration +Special$`,
+		`(?m)^    Number +Number$`,
+		`(?m)^    Documentation buffers +3$`,
+	} {
+		if !regexp.MustCompile(pat).Match(p) {
+			t.Errorf("%s not matched in\n%s", pat, p)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// sourceFile represents a parsed Go source buffer.
+type sourceFile struct {
+	fset    *token.FileSet
+	tfile   *token.File
+	file    *ast.File
+	src     []byte
+	imports map[string]string
+}
+
+// parseSource parses the Go source file read from r. Syntax errors are
+// ignored as long as the parser returns a file.
+func parseSource(r io.Reader) (*sourceFile, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if file == nil {
+		return nil, errors.New("could not parse buffer")
+	}
+	var tfile *token.File
+	fset.Iterate(func(f *token.File) bool {
+		tfile = f
+		return false
+	})
+	return &sourceFile{
+		fset:    fset,
+		tfile:   tfile,
+		file:    file,
+		src:     src,
+		imports: readImports("", bytes.NewReader(src)),
+	}, nil
+}
+
+// pos returns the position of the 1-based line and byte column.
+func (sf *sourceFile) pos(line, col int) token.Pos {
+	offset := 0
+	for i := 1; i < line && offset < len(sf.src); i++ {
+		j := bytes.IndexByte(sf.src[offset:], '\n')
+		if j < 0 {
+			offset = len(sf.src)
+			break
+		}
+		offset += j + 1
+	}
+	offset += col - 1
+	if offset > len(sf.src) {
+		offset = len(sf.src)
+	}
+	return sf.tfile.Pos(offset)
+}
+
+// enclosing returns the nodes enclosing the 1-based line and byte column,
+// innermost first.
+func (sf *sourceFile) enclosing(line, col int) []ast.Node {
+	p := sf.pos(line, col)
+	path, _ := astutil.PathEnclosingInterval(sf.file, p, p)
+	return path
+}
+
+// callTarget returns the package name and function name of the innermost
+// call expression at the 1-based line and byte column. The package name is
+// "" for a call to a function in the current package.
+func (sf *sourceFile) callTarget(line, col int) (string, string, error) {
+	for _, n := range sf.enclosing(line, col) {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		fun := call.Fun
+		for {
+			switch x := fun.(type) {
+			case *ast.ParenExpr:
+				fun = x.X
+				continue
+			case *ast.IndexExpr:
+				fun = x.X
+				continue
+			case *ast.IndexListExpr:
+				fun = x.X
+				continue
+			}
+			break
+		}
+		switch fun := fun.(type) {
+		case *ast.Ident:
+			return "", fun.Name, nil
+		case *ast.SelectorExpr:
+			if x, ok := fun.X.(*ast.Ident); ok {
+				if _, ok := sf.imports[x.Name]; ok {
+					return x.Name, fun.Sel.Name, nil
+				}
+			}
+			return "", "", errors.New("cannot resolve method call")
+		}
+		return "", "", errors.New("cannot resolve call")
+	}
+	return "", "", errors.New("no call under cursor")
+}
+
+// selectorTarget returns the package name and symbol for the innermost
+// selector expression at the 1-based line and byte column. The symbol is
+// Type.Name for a selector on a variable declared with a package qualified
+// type. The types of other expressions are not inferred.
+func (sf *sourceFile) selectorTarget(line, col int) (string, string, error) {
+	for _, n := range sf.enclosing(line, col) {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return "", "", errors.New("cannot resolve selector")
+		}
+		if _, ok := sf.imports[x.Name]; ok && x.Obj == nil {
+			return x.Name, sel.Sel.Name, nil
+		}
+		if name, typ, ok := sf.declaredType(x); ok {
+			return name, typ + "." + sel.Sel.Name, nil
+		}
+		return "", "", fmt.Errorf("cannot resolve type of %s", x.Name)
+	}
+	return "", "", errors.New("no selector under cursor")
+}
+
+// declaredType returns the package name and type name of the package
+// qualified type in the declaration of the variable id.
+func (sf *sourceFile) declaredType(id *ast.Ident) (string, string, bool) {
+	if id.Obj == nil || id.Obj.Kind != ast.Var {
+		return "", "", false
+	}
+	var typ ast.Expr
+	switch d := id.Obj.Decl.(type) {
+	case *ast.Field:
+		typ = d.Type
+	case *ast.ValueSpec:
+		typ = d.Type
+	}
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	sel, ok := typ.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	if _, ok := sf.imports[x.Name]; !ok {
+		return "", "", false
+	}
+	return x.Name, sel.Sel.Name, true
+}
+
+// identTarget returns the package name and symbol for the identifier at the
+// 1-based line and byte column. The package name is "" for an identifier
+// declared in the current package and "builtin" for a predeclared
+// identifier. The symbol is "" for the name of an imported package.
+func (sf *sourceFile) identTarget(line, col int) (string, string, error) {
+	path := sf.enclosing(line, col)
+	if len(path) == 0 {
+		return "", "", errors.New("no identifier under cursor")
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return "", "", errors.New("no identifier under cursor")
+	}
+	if len(path) > 1 {
+		if sel, ok := path[1].(*ast.SelectorExpr); ok {
+			if sel.X == id {
+				if _, ok := sf.imports[id.Name]; ok && id.Obj == nil {
+					return id.Name, "", nil
+				}
+			} else {
+				return sf.selectorTarget(line, col)
+			}
+		}
+	}
+	if id.Obj == nil && predeclared[id.Name] != notPredeclared {
+		return "builtin", id.Name, nil
+	}
+	return "", id.Name, nil
+}
+
+// localDef returns the 1-based line and byte column of the declaration in
+// the file of the identifier at the 1-based line and byte column. The
+// declaration is found for local variables, parameters, labels and package
+// level names declared in the file.
+func (sf *sourceFile) localDef(line, col int) (int, int, bool) {
+	path := sf.enclosing(line, col)
+	if len(path) == 0 {
+		return 0, 0, false
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return 0, 0, false
+	}
+	pos := id.Obj.Pos()
+	if !pos.IsValid() {
+		return 0, 0, false
+	}
+	p := sf.fset.Position(pos)
+	return p.Line, p.Column, true
+}
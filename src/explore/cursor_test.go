@@ -0,0 +1,169 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"strings"
+	"testing"
+)
+
+const cursorTestSource = `package main
+
+import (
+	"fmt"
+	str "strings"
+)
+
+func main() {
+	fmt.Println(str.ToUpper("hello"), helper())
+}
+`
+
+var callTargetTests = []struct {
+	line, col int
+	name, sym string
+}{
+	{9, 3, "fmt", "Println"},
+	{9, 7, "fmt", "Println"},
+	{9, 19, "str", "ToUpper"},
+	{9, 37, "", "helper"},
+}
+
+func TestCallTarget(t *testing.T) {
+	sf, err := parseSource(strings.NewReader(cursorTestSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range callTargetTests {
+		name, sym, err := sf.callTarget(tt.line, tt.col)
+		if err != nil {
+			t.Errorf("callTarget(%d, %d) returned error %v", tt.line, tt.col, err)
+			continue
+		}
+		if name != tt.name || sym != tt.sym {
+			t.Errorf("callTarget(%d, %d) = %q, %q, want %q, %q", tt.line, tt.col, name, sym, tt.name, tt.sym)
+		}
+	}
+	if sf.imports["str"] != "strings" {
+		t.Errorf("imports[str] = %q, want strings", sf.imports["str"])
+	}
+}
+
+const selectorTestSource = `package main
+
+import (
+	"net/http"
+	"os"
+)
+
+func handler(w http.ResponseWriter, req *http.Request) {
+	var c http.Client
+	h := req.Header
+	c.Do(req)
+	os.Exit(len(h))
+	h.Get("x")
+}
+`
+
+var selectorTargetTests = []struct {
+	line, col int
+	name, sym string
+	ok        bool
+}{
+	{10, 11, "http", "Request.Header", true},
+	{11, 4, "http", "Client.Do", true},
+	{12, 5, "os", "Exit", true},
+	{8, 20, "http", "ResponseWriter", true},
+	{13, 4, "", "", false},
+}
+
+func TestSelectorTarget(t *testing.T) {
+	sf, err := parseSource(strings.NewReader(selectorTestSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range selectorTargetTests {
+		name, sym, err := sf.selectorTarget(tt.line, tt.col)
+		if (err == nil) != tt.ok || name != tt.name || sym != tt.sym {
+			t.Errorf("selectorTarget(%d, %d) = %q, %q, %v, want %q, %q, ok=%v", tt.line, tt.col, name, sym, err, tt.name, tt.sym, tt.ok)
+		}
+	}
+}
+
+var identTargetTests = []struct {
+	line, col int
+	name, sym string
+}{
+	{9, 2, "fmt", ""},
+	{9, 8, "fmt", "Println"},
+	{9, 15, "str", ""},
+	{9, 20, "str", "ToUpper"},
+	{9, 37, "", "helper"},
+	{8, 7, "", "main"},
+}
+
+func TestIdentTarget(t *testing.T) {
+	sf, err := parseSource(strings.NewReader(cursorTestSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range identTargetTests {
+		name, sym, err := sf.identTarget(tt.line, tt.col)
+		if err != nil {
+			t.Errorf("identTarget(%d, %d) returned error %v", tt.line, tt.col, err)
+			continue
+		}
+		if name != tt.name || sym != tt.sym {
+			t.Errorf("identTarget(%d, %d) = %q, %q, want %q, %q", tt.line, tt.col, name, sym, tt.name, tt.sym)
+		}
+	}
+	sf, err = parseSource(strings.NewReader("package p\n\nvar n = len(\"x\")\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, sym, _ := sf.identTarget(3, 10); name != "builtin" || sym != "len" {
+		t.Errorf("identTarget(len) = %q, %q, want builtin, len", name, sym)
+	}
+}
+
+const localDefTestSource = `package main
+
+import "fmt"
+
+var count int
+
+func main() {
+	msg := "hello"
+	for i := 0; i < count; i++ {
+		fmt.Println(msg, i)
+	}
+}
+`
+
+var localDefTests = []struct {
+	line, col int
+	defLine   int
+	defCol    int
+	ok        bool
+}{
+	{10, 15, 8, 2, true},
+	{10, 20, 9, 6, true},
+	{9, 19, 5, 5, true},
+	{10, 3, 0, 0, false},
+	{10, 7, 0, 0, false},
+}
+
+func TestLocalDef(t *testing.T) {
+	sf, err := parseSource(strings.NewReader(localDefTestSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range localDefTests {
+		line, col, ok := sf.localDef(tt.line, tt.col)
+		if ok != tt.ok || line != tt.defLine || col != tt.defCol {
+			t.Errorf("localDef(%d, %d) = %d, %d, %v, want %d, %d, %v", tt.line, tt.col, line, col, ok, tt.defLine, tt.defCol, tt.ok)
+		}
+	}
+}
@@ -5,60 +5,357 @@
 package explore
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/build"
 	godoc "go/doc"
+	"go/printer"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 func findDef(ctx *build.Context, cwd, importPath, symbol string) (string, int, int, error) {
-	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageUnexported)
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageUnexported|loadPackageAllMethods)
 	if err != nil {
 		return "", 0, 0, err
 	}
 	if pkg.GoDoc == nil || symbol == "" {
 		return pkg.Build.Dir, 0, 0, nil
 	}
+	if decl := findDecl(pkg, symbol); decl != nil {
+		return declPosition(pkg, decl)
+	}
+	if id := findField(pkg, symbol); id != nil {
+		return declPosition(pkg, id)
+	}
+	return "", 0, 0, newError(errorSymbolNotFound, "%s not found in %s", symbol, pkg.Build.ImportPath)
+}
+
+// identDef returns the position of the declaration of the target of an
+// identifier in sf, a source file in directory dir. The package name and
+// symbol are as returned by identTarget. Names declared in the package of
+// the file are found in dir. Imported packages are found relative to cwd.
+func identDef(ctx *build.Context, cwd, dir string, sf *sourceFile, name, sym string) (string, int, int, error) {
+	switch name {
+	case "":
+		return findDef(ctx, dir, ".", sym)
+	case "builtin":
+		return findDef(ctx, cwd, "builtin", sym)
+	default:
+		return findDef(ctx, cwd, sf.imports[name], sym)
+	}
+}
+
+// findDecl returns the declaration of symbol in pkg or nil if the symbol is
+// not found. The symbol is a package level name or Type.Method. If pkg is
+// loaded with loadPackageAllMethods, then the declaration of a method
+// promoted from an embedded type is the method of the embedded type.
+func findDecl(pkg *pkg, symbol string) ast.Decl {
 	parts := strings.Split(symbol, ".")
 	if len(parts) == 2 {
 		for _, d := range pkg.GoDoc.Types {
 			if d.Name == parts[0] {
 				for _, m := range d.Methods {
 					if m.Name == parts[1] {
-						return declPosition(pkg, m.Decl)
+						return m.Decl
 					}
 				}
 				break
 			}
 		}
 	} else {
-		untangleDoc(pkg.GoDoc)
-		for _, d := range [][]*godoc.Value{pkg.GoDoc.Consts, pkg.GoDoc.Vars} {
+		doc := untangleDoc(pkg.GoDoc)
+		for _, d := range [][]*godoc.Value{doc.Consts, doc.Vars} {
 			for _, d := range d {
 				for _, name := range d.Names {
 					if name == symbol {
-						return declPosition(pkg, d.Decl)
+						return d.Decl
 					}
 				}
 			}
 		}
-		for _, d := range pkg.GoDoc.Funcs {
+		for _, d := range doc.Funcs {
 			if d.Name == symbol {
-				return declPosition(pkg, d.Decl)
+				return d.Decl
 			}
 		}
-		for _, d := range pkg.GoDoc.Types {
+		for _, d := range doc.Types {
 			if d.Name == symbol {
-				return declPosition(pkg, d.Decl)
+				return d.Decl
+			}
+		}
+	}
+	return nil
+}
+
+// findField returns the name of the struct field Type.Field in pkg or nil
+// if the field is not found.
+func findField(pkg *pkg, symbol string) *ast.Ident {
+	parts := strings.Split(symbol, ".")
+	if len(parts) != 2 {
+		return nil
+	}
+	for _, d := range pkg.GoDoc.Types {
+		if d.Name != parts[0] {
+			continue
+		}
+		for _, spec := range d.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != d.Name {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return nil
+			}
+			for _, f := range st.Fields.List {
+				for _, n := range f.Names {
+					if n.Name == parts[1] {
+						return n
+					}
+				}
 			}
 		}
 	}
-	return "", 0, 0, fmt.Errorf("%s not found in %s", symbol, pkg.Build.ImportPath)
+	return nil
 }
 
 func declPosition(pkg *pkg, n ast.Node) (string, int, int, error) {
 	p := pkg.FSet.Position(n.Pos())
 	return filepath.Join(pkg.Build.Dir, p.Filename), p.Line, p.Column, nil
 }
+
+// fileLine splits a specification of the form file.go:line into the file
+// name and line number.
+func fileLine(spec string) (string, int, bool) {
+	i := strings.LastIndex(spec, ":")
+	if i < 0 || !strings.HasSuffix(spec[:i], ".go") {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(spec[i+1:])
+	if err != nil || line <= 0 {
+		return "", 0, false
+	}
+	return spec[:i], line, true
+}
+
+// declAtLine returns the symbol declared by the top-level declaration
+// enclosing line in the file fname of package importPath. The declaration
+// includes its doc comment. The empty string is returned if line is not in a
+// declaration.
+func declAtLine(ctx *build.Context, cwd, importPath, fname string, line int) (string, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, 0)
+	if err != nil {
+		return "", err
+	}
+	file := pkg.AST.Files[filepath.Base(fname)]
+	if file == nil {
+		return "", fmt.Errorf("%s not found in %s", filepath.Base(fname), pkg.Build.ImportPath)
+	}
+	contains := func(start, end ast.Node) bool {
+		return pkg.FSet.Position(start.Pos()).Line <= line && line <= pkg.FSet.Position(end.End()).Line
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			start := ast.Node(decl)
+			if decl.Doc != nil {
+				start = decl.Doc
+			}
+			if !contains(start, decl) {
+				continue
+			}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				if name := receiverName(decl.Recv.List[0].Type); name != "" {
+					return name + "." + decl.Name.Name, nil
+				}
+			}
+			return decl.Name.Name, nil
+		case *ast.GenDecl:
+			start := ast.Node(decl)
+			if decl.Doc != nil {
+				start = decl.Doc
+			}
+			if !contains(start, decl) || len(decl.Specs) == 0 {
+				continue
+			}
+			// Use the spec containing the line or the first spec.
+			spec := decl.Specs[0]
+			for _, s := range decl.Specs {
+				if contains(s, s) {
+					spec = s
+				}
+			}
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				return spec.Name.Name, nil
+			case *ast.ValueSpec:
+				return spec.Names[0].Name, nil
+			}
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+// receiverName returns the name of the type in a method receiver or "" if
+// the type is not a named type.
+func receiverName(x ast.Expr) string {
+	for {
+		switch t := x.(type) {
+		case *ast.StarExpr:
+			x = t.X
+		case *ast.ParenExpr:
+			x = t.X
+		case *ast.IndexExpr:
+			x = t.X
+		case *ast.IndexListExpr:
+			x = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// returnType returns the import path and name of the type returned by the
+// function symbol in package importPath. The function must return a single
+// named type, optionally followed by an error.
+func returnType(ctx *build.Context, cwd, importPath, symbol string) (string, string, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageUnexported)
+	if err != nil {
+		return "", "", err
+	}
+	if pkg.GoDoc == nil {
+		return "", "", newError(errorSymbolNotFound, "%s not found in %s", symbol, importPath)
+	}
+	decl, ok := findDecl(pkg, symbol).(*ast.FuncDecl)
+	if !ok {
+		return "", "", newError(errorSymbolNotFound, "function %s not found in %s", symbol, importPath)
+	}
+	var results []ast.Expr
+	if decl.Type.Results != nil {
+		for _, f := range decl.Type.Results.List {
+			n := len(f.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				results = append(results, f.Type)
+			}
+		}
+	}
+	if n := len(results); n == 2 {
+		if id, ok := results[1].(*ast.Ident); ok && id.Name == "error" && id.Obj == nil {
+			results = results[:1]
+		}
+	}
+	if len(results) != 1 {
+		return "", "", fmt.Errorf("%s does not return a single type", symbol)
+	}
+	typ := results[0]
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		if predeclared[typ.Name] != notPredeclared && typ.Obj == nil {
+			return "builtin", typ.Name, nil
+		}
+		return importPath, typ.Name, nil
+	case *ast.SelectorExpr:
+		if x, ok := typ.X.(*ast.Ident); ok && x.Obj != nil && x.Obj.Kind == ast.Pkg {
+			if spec, ok := x.Obj.Decl.(*ast.ImportSpec); ok {
+				if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+					return path, typ.Sel.Name, nil
+				}
+			}
+		}
+	}
+	return "", "", fmt.Errorf("return type of %s is not a named type", symbol)
+}
+
+// whatis returns a one-line description of symbol in the package
+// importPath: the kind of the symbol, its package and its declaration
+// without a body or the fields and methods of a type.
+func whatis(ctx *build.Context, cwd, importPath, symbol string) (string, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageUnexported)
+	if err != nil {
+		return "", err
+	}
+	if symbol == "" {
+		return fmt.Sprintf("package %s %q", pkg.Build.Name, pkg.Build.ImportPath), nil
+	}
+	if pkg.GoDoc == nil {
+		return "", newError(errorSymbolNotFound, "%s not found in %s", symbol, pkg.Build.ImportPath)
+	}
+	var kind string
+	var sig ast.Node
+	switch decl := findDecl(pkg, symbol).(type) {
+	case *ast.FuncDecl:
+		kind = "func"
+		if decl.Recv != nil {
+			kind = "method"
+		}
+		d := *decl
+		d.Doc, d.Body = nil, nil
+		sig = &d
+	case *ast.GenDecl:
+		kind = decl.Tok.String()
+		sig = declSpec(decl, symbol)
+	default:
+		id := findField(pkg, symbol)
+		if id == nil {
+			return "", newError(errorSymbolNotFound, "%s not found in %s", symbol, pkg.Build.ImportPath)
+		}
+		kind = "field"
+		sig = id
+		if id.Obj != nil {
+			if f, ok := id.Obj.Decl.(*ast.Field); ok {
+				sig = &ast.ValueSpec{Names: []*ast.Ident{id}, Type: f.Type}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	(&printer.Config{Mode: printer.RawFormat}).Fprint(&buf, pkg.FSet, sig)
+	return fmt.Sprintf("%s.%s is a %s: %s", pkg.Build.ImportPath, symbol, kind, oneLine(buf.String(), textWidth)), nil
+}
+
+// declSpec returns a declaration of the single name from decl. The fields
+// and methods of a struct or interface type are elided.
+func declSpec(decl *ast.GenDecl, name string) ast.Node {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if spec.Name.Name != name {
+				continue
+			}
+			s := *spec
+			switch t := spec.Type.(type) {
+			case *ast.StructType:
+				s.Type = &ast.StructType{Struct: t.Struct, Fields: emptyFieldList(t.Fields)}
+			case *ast.InterfaceType:
+				s.Type = &ast.InterfaceType{Interface: t.Interface, Methods: emptyFieldList(t.Methods)}
+			}
+			s.Doc, s.Comment = nil, nil
+			return &ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{&s}}
+		case *ast.ValueSpec:
+			for _, id := range spec.Names {
+				if id.Name == name {
+					s := &ast.ValueSpec{Names: []*ast.Ident{id}, Type: spec.Type}
+					return &ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{s}}
+				}
+			}
+		}
+	}
+	return decl
+}
+
+// emptyFieldList returns an empty field list that the printer prints on one
+// line as {}.
+func emptyFieldList(fl *ast.FieldList) *ast.FieldList {
+	return &ast.FieldList{Opening: fl.Opening, Closing: fl.Opening + 1}
+}
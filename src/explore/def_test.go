@@ -0,0 +1,182 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+var findDefTests = []struct {
+	importPath, sym, file string
+}{
+	{"net/http", "Request", "request.go"},
+	{"net/http", "Request.Header", "request.go"},
+	{"net/http", "Request.Write", "request.go"},
+	{"net/http", "Client.Do", "client.go"},
+}
+
+func TestFindDef(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range findDefTests {
+		file, line, _, err := findDef(&ctx.Build, cwd, tt.importPath, tt.sym)
+		if err != nil {
+			t.Errorf("findDef(%q, %q) returned error %v", tt.importPath, tt.sym, err)
+			continue
+		}
+		if filepath.Base(file) != tt.file || line == 0 {
+			t.Errorf("findDef(%q, %q) = %s:%d, want %s", tt.importPath, tt.sym, file, line, tt.file)
+		}
+	}
+	if _, _, _, err := findDef(&ctx.Build, cwd, "net/http", "Request.NoSuchField"); err == nil {
+		t.Errorf("findDef(net/http, Request.NoSuchField) did not return error")
+	}
+}
+
+func TestIdentDef(t *testing.T) {
+	const src = "package p\n\nfunc f() { g() }\n"
+	dir := writeModule(t, map[string]string{
+		"go.mod":  "module example.com/m\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+		"p/a.go":  src,
+		"p/b.go":  "package p\n\nfunc g() {}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	sf, err := parseSource(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, sym, err := sf.identTarget(3, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Get(&context.Env{})
+	file, line, _, err := identDef(&ctx.Build, dir, filepath.Join(dir, "p"), sf, name, sym)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "p", "b.go"); file != want || line != 3 {
+		t.Errorf("identDef(g) = %s:%d, want %s:3", file, line, want)
+	}
+}
+
+var declAtLineTests = []struct {
+	dir  string
+	spec string
+	want string
+}{
+	{"./testdata/links", "links.go:1", ""},
+	{"./testdata/links", "links.go:9", "Value"},
+	{"./testdata/links", "links.go:10", "Value"},
+	{"./testdata/links", "links.go:13", "Value.String"},
+	{"./testdata/iota", "iota.go:5", "Kind"},
+	{"./testdata/iota", "iota.go:7", "A"},
+	{"./testdata/iota", "iota.go:11", "D"},
+	{"./testdata/iota", "iota.go:15", ""},
+}
+
+func TestDeclAtLine(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range declAtLineTests {
+		fname, line, ok := fileLine(tt.spec)
+		if !ok {
+			t.Errorf("fileLine(%q) not ok", tt.spec)
+			continue
+		}
+		got, err := declAtLine(&ctx.Build, cwd, tt.dir, fname, line)
+		if err != nil || got != tt.want {
+			t.Errorf("declAtLine(%q, %q) = %q, %v, want %q", tt.dir, tt.spec, got, err, tt.want)
+		}
+	}
+	for _, spec := range []string{"links.go", "links.go:", "links.go:x", "links.go:0", "links:10"} {
+		if _, _, ok := fileLine(spec); ok {
+			t.Errorf("fileLine(%q) ok", spec)
+		}
+	}
+}
+
+var promotedMethodTests = []struct {
+	sym  string
+	line int
+}{
+	{"Inner.Hello", 8},
+	{"Outer.Hello", 8},
+	{"Outer.Goodbye", 11},
+	{"Outer.Own", 19},
+}
+
+func TestPromotedMethods(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range promotedMethodTests {
+		file, line, _, err := findDef(&ctx.Build, cwd, "./testdata/embed", tt.sym)
+		if err != nil || filepath.Base(file) != "embed.go" || line != tt.line {
+			t.Errorf("findDef(%q) = %s:%d, %v, want embed.go:%d", tt.sym, file, line, err, tt.line)
+		}
+	}
+}
+
+var returnTypeTests = []struct {
+	importPath, sym   string
+	wantPath, wantTyp string
+}{
+	{"net/http", "Get", "net/http", "Response"},
+	{"net/http", "NewRequest", "net/http", "Request"},
+	{"bufio", "NewReader", "bufio", "Reader"},
+	{"io/ioutil", "NopCloser", "io", "ReadCloser"},
+	{"strings", "Repeat", "builtin", "string"},
+	{"io", "ReadAll", "", ""},
+	{"context", "WithCancel", "", ""},
+	{"os", "Exit", "", ""},
+}
+
+func TestReturnType(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range returnTypeTests {
+		path, typ, err := returnType(&ctx.Build, cwd, tt.importPath, tt.sym)
+		if path != tt.wantPath || typ != tt.wantTyp || (err != nil) != (tt.wantPath == "") {
+			t.Errorf("returnType(%q, %q) = %q, %q, %v, want %q, %q", tt.importPath, tt.sym, path, typ, err, tt.wantPath, tt.wantTyp)
+		}
+	}
+}
+
+var whatisTests = []struct {
+	importPath, sym, want string
+}{
+	{"strings", "Index", "strings.Index is a func: func Index(s, substr string) int"},
+	{"strings", "Builder", "strings.Builder is a type: type Builder struct{}"},
+	{"strings", "Builder.Len", "strings.Builder.Len is a method: func (b *Builder) Len() int"},
+	{"io", "EOF", "io.EOF is a var: var EOF"},
+	{"io", "SeekStart", "io.SeekStart is a const: const SeekStart"},
+	{"net/http", "Request.Method", "net/http.Request.Method is a field: Method string"},
+	{"builtin", "len", "builtin.len is a func: func len(v Type) int"},
+	{"./testdata/links", "", `package links "./testdata/links"`},
+}
+
+func TestWhatis(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range whatisTests {
+		got, err := whatis(&ctx.Build, cwd, tt.importPath, tt.sym)
+		if err != nil {
+			t.Errorf("whatis(%q, %q) returned error %v", tt.importPath, tt.sym, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("whatis(%q, %q) = %q, want %q", tt.importPath, tt.sym, got, tt.want)
+		}
+	}
+	if _, err := whatis(&ctx.Build, cwd, "strings", "nosuch"); errorKind(err) != errorSymbolNotFound {
+		t.Errorf("whatis(strings, nosuch) error = %v, want symbol not found", err)
+	}
+}
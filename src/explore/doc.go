@@ -6,60 +6,239 @@ package explore
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/build"
 	godoc "go/doc"
+	"go/doc/comment"
 	"go/printer"
 	"go/scanner"
 	"go/token"
-	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/garyburd/vigor/src/doc"
+	"golang.org/x/tools/go/buildutil"
 )
 
 const (
-	headerGroup  = "Constant"
-	commentGroup = "Comment"
-	declGroup    = "Special"
-	textIndent   = "    "
-	textWidth    = 80 - len(textIndent)
+	headerGroup     = "Constant"
+	commentGroup    = "Comment"
+	declGroup       = "Special"
+	deprecatedGroup = "WarningMsg"
+	badgeGroup      = "Todo"
+	outputGroup     = "String"
+	stringGroup     = "String"
+	numberGroup     = "Number"
+	textIndent      = "    "
+	textWidth       = 80 - len(textIndent)
 )
 
 // bufNamePrefix specifies the file name prefix for documentation pages.
 const bufNamePrefix = "godoc://"
 
+// docOptions specifies optional features of a documentation page. The
+// fields are set from g:vigor_* variables.
+type docOptions struct {
+	// RuntimeNotes enables the section listing GODEBUG settings mentioned in
+	// the package comments.
+	RuntimeNotes bool `eval:"get(g:, 'vigor_runtime_notes', 0)"`
+
+	// FullImportPaths specifies that package references in declarations are
+	// shown as the full import path instead of the package name. The option
+	// is toggled per buffer.
+	FullImportPaths bool `eval:"get(b:, 'vigor_full_import_paths', get(g:, 'vigor_full_import_paths', 0))"`
+
+	// MaxElements and MaxStringLength are the limits for displaying
+	// composite literal elements and string literals in declarations. Zero
+	// specifies no limit.
+	MaxElements     int `eval:"get(g:, 'vigor_max_elements', 100)"`
+	MaxStringLength int `eval:"get(g:, 'vigor_max_string_length', 128)"`
+
+	// Full specifies that declarations are displayed without limits. The
+	// option is set per buffer by :Godoc -full.
+	Full bool `eval:"get(b:, 'vigor_full', 0)"`
+
+	// Detail is the detail level of the page. The level is changed per
+	// buffer with the + and _ mappings.
+	Detail int `eval:"get(b:, 'vigor_detail', get(g:, 'vigor_detail', 2))"`
+
+	// LoadTimeout is the maximum time in milliseconds to load the package.
+	// Zero specifies no limit.
+	LoadTimeout int `eval:"get(g:, 'vigor_load_timeout', 10000)"`
+
+	// VarValues enables line comments with the values of package level
+	// variables initialized with constant expressions. The package is type
+	// checked to compute the values.
+	VarValues bool `eval:"get(g:, 'vigor_var_values', 0)"`
+
+	// ExampleStatus enables a note after each example saying how go test
+	// checks the example.
+	ExampleStatus bool `eval:"get(g:, 'vigor_example_status', 0)"`
+
+	// FoldDeclLines is the number of lines in a declaration above which the
+	// declaration is folded below its first line. Zero disables folding.
+	FoldDeclLines int `eval:"get(g:, 'vigor_fold_decl_lines', 12)"`
+
+	// HideExamples hides the examples at detail levels that include
+	// examples. The option is toggled per buffer by :Godocexamples.
+	HideExamples bool `eval:"get(b:, 'vigor_hide_examples', get(g:, 'vigor_hide_examples', 0))"`
+
+	// StdPackages is the placement of the standard packages on the root
+	// page: "first", "last" or "hide".
+	StdPackages string `eval:"get(g:, 'vigor_std_packages', 'first')"`
+
+	// Index is the index of exported symbols printed after the package
+	// overview: "" for no index, "names" or "signatures". Signatures are
+	// printed on one line and truncated to the width of the page.
+	Index string `eval:"get(g:, 'vigor_index', '')"`
+}
+
+// Detail levels. Each level includes the information in the previous levels.
+const (
+	detailSignatures = iota
+	detailDoc
+	detailExamples
+	detailSource
+)
+
 // printDoc prints the documentation for the given import path.
-func printDoc(ctx *build.Context, path string, cwd string) (*doc.Doc, error) {
-	importPath := strings.TrimPrefix(path, bufNamePrefix)
+func printDoc(ctx *build.Context, path string, cwd string, options *docOptions) (*doc.Doc, error) {
+	ctx, importPath := parseDocName(ctx, path)
 	p := docPrinter{
 		Doc:        doc.NewDoc(),
+		ctx:        ctx,
 		importPath: importPath,
+		options:    options,
 	}
 	if importPath != "" {
-		pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageExamples|loadPackageFixVendor)
+		flags := loadPackageDoc | loadPackageExamples | loadPackageFixVendor
+		if options.Detail >= detailSource {
+			flags |= loadPackagePreserveAST
+		}
+		cctx := context.Background()
+		if options.LoadTimeout > 0 {
+			var cancel context.CancelFunc
+			cctx, cancel = context.WithTimeout(cctx, time.Duration(options.LoadTimeout)*time.Millisecond)
+			defer cancel()
+		}
+		pkg, err := loadPackageContext(cctx, ctx, importPath, cwd, flags)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("loading %s took longer than %d ms; see g:vigor_load_timeout", importPath, options.LoadTimeout)
+		}
 		if err != nil {
 			return nil, err
 		}
 		p.pkg = pkg
+		p.Watch(pkg.Build.Dir)
+		for _, fnames := range [][]string{pkg.Build.GoFiles, pkg.Build.CgoFiles, pkg.Build.TestGoFiles, pkg.Build.XTestGoFiles} {
+			for _, fname := range fnames {
+				p.Watch(filepath.Join(pkg.Build.Dir, fname))
+			}
+		}
 	}
 	return p.execute()
 }
 
+// printPackageOverview prints the package doc comment for the package
+// named by importPath. The page has no declarations, examples or directory
+// listings. Links to declarations in the package are links to the
+// documentation page for the package.
+func printPackageOverview(ctx *build.Context, importPath string, cwd string) (*doc.Doc, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc|loadPackageFixVendor)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.GoDoc == nil {
+		return nil, fmt.Errorf("no Go files in %s", pkg.Build.Dir)
+	}
+	p := docPrinter{
+		pkg:        pkg,
+		Doc:        doc.NewDoc(),
+		ctx:        ctx,
+		importPath: importPath,
+		options:    &docOptions{Detail: detailDoc},
+		linkPage:   docName(pkg.Build.ImportPath, ""),
+	}
+	p.PushHighlight(declGroup)
+	p.WriteString("package ")
+	p.WriteLinkAnchor(pkg.GoDoc.Name, p.linkPage, "")
+	p.PushHighlight(commentGroup)
+	fmt.Fprintf(p.Doc, " // import \"%s\"\n\n", pkg.Build.ImportPath)
+	p.PopHighlight()
+	p.PopHighlight()
+	if strings.TrimSpace(pkg.GoDoc.Doc) == "" {
+		p.WriteString(textIndent + "No package documentation.\n")
+	}
+	p.printText(pkg.GoDoc.Doc)
+	return p.Doc, nil
+}
+
+// tagsQuery is the separator between the import path and the build tags in
+// the name of a documentation page.
+const tagsQuery = "?tags="
+
+// docName returns the name of the documentation page for the import path.
+// The build tags, if any, are encoded in the name.
+func docName(importPath string, tags string) string {
+	if tags != "" {
+		importPath += tagsQuery + tags
+	}
+	return bufNamePrefix + importPath
+}
+
+// parseDocName returns the build context and import path for the
+// documentation page with the given name. If the name specifies build tags,
+// then the returned context is a copy of ctx with the tags.
+func parseDocName(ctx *build.Context, name string) (*build.Context, string) {
+	importPath := strings.TrimPrefix(name, bufNamePrefix)
+	if i := strings.Index(importPath, tagsQuery); i >= 0 {
+		ctx = withTags(ctx, importPath[i+len(tagsQuery):])
+		importPath = importPath[:i]
+	}
+	return ctx, importPath
+}
+
+// withTags returns a copy of ctx with the comma separated build tags. The
+// tags replace the build tags in ctx.
+func withTags(ctx *build.Context, tags string) *build.Context {
+	c := *ctx
+	c.BuildTags = strings.Split(tags, ",")
+	return &c
+}
+
 // docPrinter holds state used to create a documentation page.
 type docPrinter struct {
 	*pkg
 	*doc.Doc
+	ctx        *build.Context
 	importPath string
+	options    *docOptions
 	scratch    bytes.Buffer
+
+	// varValues caches the values of the package level variables for
+	// varComments.
+	varValues map[string]string
+
+	// linkPage is the page for links to declarations in the package. The
+	// links are to the current page when linkPage is "".
+	linkPage string
+
+	// sections maps the names of page sections to the line of the start of
+	// the section. The sections are set in b:vigor_sections for the
+	// section mappings.
+	sections map[string]int
 }
 
 func (p *docPrinter) execute() (*doc.Doc, error) {
@@ -69,19 +248,25 @@ func (p *docPrinter) execute() (*doc.Doc, error) {
 	case p.importPath == "":
 		// root
 	case p.GoDoc == nil:
+		p.markSection(packageSection)
 		p.PushHighlight(headerGroup)
 		p.WriteString("Directory ")
 		p.WriteLinkAnchor(p.Build.ImportPath, p.Build.Dir, "")
 		p.PopHighlight()
 		p.WriteString("\n\n")
+		p.printErrors()
 	case p.GoDoc.Name == "main":
+		p.markSection(packageSection)
 		p.PushHighlight(headerGroup)
 		p.WriteString("Command ")
 		p.WriteLinkAnchor(path.Base(p.Build.ImportPath), p.Build.Dir, "")
 		p.PopHighlight()
 		p.WriteString("\n\n")
-		p.printText(p.GoDoc.Doc)
+		p.printPackageNotes()
+		p.printErrors()
+		p.printOverview(p.GoDoc.Doc)
 	default:
+		p.markSection(packageSection)
 		p.PushHighlight(declGroup)
 		p.WriteString("package ")
 		p.WriteLinkAnchor(p.GoDoc.Name, p.Build.Dir, "")
@@ -89,8 +274,16 @@ func (p *docPrinter) execute() (*doc.Doc, error) {
 		fmt.Fprintf(p.Doc, " // import \"%s\"\n\n", p.Build.ImportPath)
 		p.PopHighlight()
 		p.PopHighlight()
-		p.printText(p.GoDoc.Doc)
+		if !build.IsLocalImport(p.Build.ImportPath) {
+			p.SetVar("vigor_import", importStatement(p.GoDoc.Name, p.Build.ImportPath))
+		}
+		p.printPackageNotes()
+		p.printErrors()
+		p.printOverview(p.GoDoc.Doc)
 		p.printExamples("")
+		if p.options.Index != "" {
+			p.printIndex()
+		}
 		printDecls = true
 	}
 
@@ -113,8 +306,8 @@ func (p *docPrinter) execute() (*doc.Doc, error) {
 		if len(p.GoDoc.Types) > 0 {
 			p.printHeader("Types")
 			for _, d := range p.GoDoc.Types {
-				p.printDecl(d.Decl)
-				p.printText(d.Doc)
+				p.printDecl(d.Decl, d.Doc)
+				p.printDocText(d.Doc)
 				p.printExamples(d.Name)
 				p.printValues(d.Consts)
 				p.printValues(d.Vars)
@@ -123,19 +316,58 @@ func (p *docPrinter) execute() (*doc.Doc, error) {
 			}
 		}
 
+		if p.options.RuntimeNotes {
+			p.printRuntimeNotes()
+		}
+
 		p.printImports()
+		p.printNotes()
 	}
 
 	if p.importPath == "" {
-		p.printDirs("Standard Packages", []string{build.Default.GOROOT})
-		p.printDirs("Third Party Packages", filepath.SplitList(build.Default.GOPATH))
-	} else {
-		p.printDirs("Directories", append(filepath.SplitList(build.Default.GOPATH), build.Default.GOROOT))
+		// Group the root page by source root. Each group is folded.
+		if p.options.StdPackages == "first" || p.options.StdPackages == "" {
+			p.printStdDirs()
+		}
+		roots := filepath.SplitList(p.ctx.GOPATH)
+		if p.hasDirs(roots) {
+			p.printHeader("Third Party Packages")
+			for _, root := range roots {
+				p.printDirs("", root, []string{root})
+			}
+		}
+		p.printModuleCache()
+		if p.options.StdPackages == "last" {
+			p.printStdDirs()
+		}
+	} else if !build.IsLocalImport(p.importPath) {
+		p.printDirs("Directories", "", append(filepath.SplitList(p.ctx.GOPATH), p.ctx.GOROOT))
+		p.printLicense()
 	}
 
+	if len(p.sections) > 0 {
+		p.SetVar("vigor_sections", p.sections)
+	}
 	return p.Doc, nil
 }
 
+// Names of the page sections recorded by markSection.
+const (
+	packageSection     = "package"
+	directoriesSection = "directories"
+)
+
+// markSection records the current line as the start of the named section.
+// The first mark for a name is kept.
+func (p *docPrinter) markSection(name string) {
+	if p.sections == nil {
+		p.sections = make(map[string]int)
+	}
+	if _, ok := p.sections[name]; !ok {
+		p.sections[name] = p.Line()
+	}
+}
+
 const (
 	noAnnotation = iota
 	anchorAnnotation
@@ -143,17 +375,92 @@ const (
 	linkAnnotation
 	startLinkAnnotation
 	endLinkAnnotation
+	cgoAnnotation
 )
 
+// cgoImportPath is the import path of the documentation for the cgo
+// pseudo-package "C".
+const cgoImportPath = "cmd/cgo"
+
+// specURL is the URL of the Go language specification.
+const specURL = "https://golang.org/ref/spec"
+
+// specSections maps keywords to the section of the language specification
+// describing the keyword.
+var specSections = map[token.Token]string{
+	token.BREAK:       "Break_statements",
+	token.CASE:        "Switch_statements",
+	token.CHAN:        "Channel_types",
+	token.CONST:       "Constant_declarations",
+	token.CONTINUE:    "Continue_statements",
+	token.DEFAULT:     "Switch_statements",
+	token.DEFER:       "Defer_statements",
+	token.ELSE:        "If_statements",
+	token.FALLTHROUGH: "Fallthrough_statements",
+	token.FOR:         "For_statements",
+	token.FUNC:        "Function_types",
+	token.GO:          "Go_statements",
+	token.GOTO:        "Goto_statements",
+	token.IF:          "If_statements",
+	token.IMPORT:      "Import_declarations",
+	token.INTERFACE:   "Interface_types",
+	token.MAP:         "Map_types",
+	token.PACKAGE:     "Package_clause",
+	token.RANGE:       "For_range",
+	token.RETURN:      "Return_statements",
+	token.SELECT:      "Select_statements",
+	token.STRUCT:      "Struct_types",
+	token.SWITCH:      "Switch_statements",
+	token.TYPE:        "Type_declarations",
+	token.VAR:         "Variable_declarations",
+}
+
 type annotation struct {
 	kind int
 	data string
 	pos  token.Pos
 }
 
-func (p *docPrinter) printDecl(decl ast.Decl) {
+// printDecl prints decl. The doc comment text doc is used to mark deprecated
+// declarations and is printed separately by the caller.
+func (p *docPrinter) printDecl(decl ast.Decl, doc string) {
+	// Print function bodies separately because the declVisitor does not
+	// annotate the identifiers in bodies. Doc comments are printed
+	// separately and are only present in the AST with loadPackagePreserveAST.
+	// The package is shared with other callers through the package cache,
+	// so a copy of the declaration is printed.
+	var body *ast.BlockStmt
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		body = d.Body
+		fd := *d
+		fd.Body, fd.Doc = nil, nil
+		decl = &fd
+	case *ast.GenDecl:
+		gd := *d
+		gd.Doc = nil
+		decl = &gd
+	}
+
 	v := &declVisitor{}
+	if !p.options.Full {
+		v.maxElements = p.options.MaxElements
+		v.maxStringLength = p.options.MaxStringLength
+	}
 	ast.Walk(v, decl)
+	if d, ok := decl.(*ast.GenDecl); ok {
+		v.comments = append(v.comments, p.iotaComments(d)...)
+		v.comments = append(v.comments, p.varComments(d)...)
+	}
+	if len(v.elided) > 0 {
+		decl = replaceNodes(decl, v.elided).(ast.Decl)
+	}
+	if len(v.comments) > 0 {
+		// The printer only prints the comments in the list when the list is
+		// not empty. Add the comments attached to nodes in the declaration.
+		v.comments = append(v.comments, nodeComments(decl)...)
+		sort.Sort(byPos(v.comments))
+	}
 	p.scratch.Reset()
 	err := (&printer.Config{Tabwidth: 4}).Fprint(
 		&p.scratch,
@@ -165,12 +472,32 @@ func (p *docPrinter) printDecl(decl ast.Decl) {
 	}
 	buf := bytes.TrimRight(p.scratch.Bytes(), " \t\n")
 
+	// Write the deprecation marker at the end of the first line so that
+	// the marker is visible when the declaration is folded.
+	marker := p.declDeprecated(decl, doc)
+	write := func(b []byte) {
+		if marker {
+			if i := bytes.IndexByte(b, '\n'); i >= 0 {
+				p.Write(b[:i])
+				p.writeDeprecatedMarker()
+				b = b[i:]
+				marker = false
+			}
+		}
+		p.Write(b)
+	}
+
 	var s scanner.Scanner
 	fset := token.NewFileSet()
 	file := fset.AddFile("", fset.Base(), len(buf))
 	base := file.Base()
 	s.Init(file, buf, nil, scanner.ScanComments)
 	lastOffset := 0
+	declTok := token.FUNC
+	if d, ok := decl.(*ast.GenDecl); ok {
+		declTok = d.Tok
+	}
+	start := p.Line()
 	p.PushHighlight(declGroup)
 	defer p.PopHighlight()
 loop:
@@ -181,21 +508,26 @@ loop:
 			break loop
 		case token.COMMENT:
 			offset := int(pos) - base
-			p.Write(buf[lastOffset:offset])
+			write(buf[lastOffset:offset])
 			lastOffset = offset + len(lit)
 			p.PushHighlight(commentGroup)
 			p.WriteString(lit)
 			p.PopHighlight()
 		case token.IDENT:
-			if len(v.annotations) == 0 {
-				// Oops!
-				break loop
-			}
 			offset := int(pos) - base
-			p.Write(buf[lastOffset:offset])
+			write(buf[lastOffset:offset])
 			lastOffset = offset + len(lit)
-			a := v.annotations[0]
-			v.annotations = v.annotations[1:]
+			// The declVisitor annotates each identifier printed by the
+			// printer. Write any identifiers past the end of the
+			// annotations as plain text instead of dropping them.
+			a := &annotation{kind: noAnnotation}
+			if len(v.annotations) > 0 {
+				a = v.annotations[0]
+				v.annotations = v.annotations[1:]
+			}
+			if a.kind == startLinkAnnotation && len(v.annotations) == 0 {
+				a = &annotation{kind: noAnnotation}
+			}
 			switch a.kind {
 			case startLinkAnnotation:
 				file := ""
@@ -203,6 +535,9 @@ loop:
 					file = bufNamePrefix + a.data
 				}
 				p.PushLinkAnchor(file, v.annotations[0].data)
+				if p.options.FullImportPaths && a.data != "" {
+					lit = a.data
+				}
 				p.WriteString(lit)
 			case endLinkAnnotation:
 				p.WriteString(lit)
@@ -214,9 +549,14 @@ loop:
 				}
 				p.WriteLinkAnchor(lit, file, lit)
 			case packageLinkAnnoation:
+				if p.options.FullImportPaths {
+					lit = a.data
+				}
 				p.WriteLinkAnchor(lit, bufNamePrefix+a.data, "")
+			case cgoAnnotation:
+				p.WriteLinkAnchor(lit, bufNamePrefix+cgoImportPath, "")
 			case anchorAnnotation:
-				p.addAnchor(lit, a.data)
+				p.addAnchor(lit, a.data, declTok)
 				pos := p.FSet.Position(a.pos)
 				p.WriteLink(lit,
 					filepath.Join(p.Build.Dir, pos.Filename),
@@ -224,36 +564,283 @@ loop:
 			default:
 				p.WriteString(lit)
 			}
+		case token.STRING, token.CHAR, token.INT, token.FLOAT, token.IMAG:
+			offset := int(pos) - base
+			write(buf[lastOffset:offset])
+			lastOffset = offset + len(lit)
+			group := numberGroup
+			if tok == token.STRING || tok == token.CHAR {
+				group = stringGroup
+			}
+			p.PushHighlight(group)
+			write([]byte(lit))
+			p.PopHighlight()
+		default:
+			if section, ok := specSections[tok]; ok {
+				offset := int(pos) - base
+				write(buf[lastOffset:offset])
+				lastOffset = offset + len(lit)
+				p.WriteLinkAnchor(lit, specURL+"#"+section, "")
+			}
 		}
 	}
-	p.Write(buf[lastOffset:])
+	write(buf[lastOffset:])
+	if marker {
+		p.writeDeprecatedMarker()
+	}
+	if body != nil && p.options.Detail >= detailSource {
+		p.printBody(body)
+	}
+	if n := p.options.FoldDeclLines; n > 0 && p.Line()-start+1 > n {
+		// Keep the first line of the declaration visible.
+		p.AddFold(start+1, p.Line())
+	}
 	p.WriteString("\n\n")
 }
 
+// declDeprecated returns whether the declaration with the doc comment text
+// doc is deprecated. The declaration is deprecated if the doc comment has a
+// deprecation paragraph or a DEPRECATED note, or if a DEPRECATED note is in
+// the declaration.
+func (p *docPrinter) declDeprecated(decl ast.Decl, doc string) bool {
+	for _, para := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(para, "Deprecated:") || strings.HasPrefix(para, "DEPRECATED(") {
+			return true
+		}
+	}
+	for _, note := range p.GoDoc.Notes["DEPRECATED"] {
+		if decl.Pos() <= note.Pos && note.Pos < decl.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDeprecatedMarker writes the marker for a deprecated declaration.
+func (p *docPrinter) writeDeprecatedMarker() {
+	p.WriteString(" ")
+	p.PushHighlight(deprecatedGroup)
+	p.WriteString("[deprecated]")
+	p.PopHighlight()
+}
+
+// nodeComments returns the doc and line comments attached to the specs and
+// fields in n.
+func nodeComments(n ast.Node) []*ast.CommentGroup {
+	var comments []*ast.CommentGroup
+	add := func(cgs ...*ast.CommentGroup) {
+		for _, cg := range cgs {
+			if cg != nil {
+				comments = append(comments, cg)
+			}
+		}
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			add(n.Doc, n.Comment)
+		case *ast.ValueSpec:
+			add(n.Doc, n.Comment)
+		case *ast.TypeSpec:
+			add(n.Doc, n.Comment)
+		}
+		return true
+	})
+	return comments
+}
+
+type byPos []*ast.CommentGroup
+
+func (s byPos) Len() int           { return len(s) }
+func (s byPos) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byPos) Less(i, j int) bool { return s[i].Pos() < s[j].Pos() }
+
+// printBody prints a function body with the comments in the body.
+func (p *docPrinter) printBody(body *ast.BlockStmt) {
+	var comments []*ast.CommentGroup
+	for _, f := range p.AST.Files {
+		for _, cg := range f.Comments {
+			if body.Pos() <= cg.Pos() && cg.End() <= body.End() {
+				comments = append(comments, cg)
+			}
+		}
+	}
+	p.scratch.Reset()
+	err := (&printer.Config{Tabwidth: 4}).Fprint(
+		&p.scratch,
+		p.FSet,
+		&printer.CommentedNode{Node: body, Comments: comments})
+	if err != nil {
+		return
+	}
+	p.WriteString(" ")
+	p.Write(p.scratch.Bytes())
+}
+
+// importStatement returns the import statement for the package. The
+// statement includes the package name if the name differs from the name
+// guessed from the import path.
+func importStatement(name, importPath string) string {
+	if name != guessPackageNameFromPath(importPath) {
+		return fmt.Sprintf("import %s %q", name, importPath)
+	}
+	return fmt.Sprintf("import %q", importPath)
+}
+
+// printPackageNotes prints the module containing the package and, for
+// packages under development, the time the package was last modified.
+func (p *docPrinter) printPackageNotes() {
+	var notes []string
+	if modPath, version := moduleForDir(p.Build.Dir); modPath != "" {
+		if version != "" {
+			modPath += " " + version
+		}
+		notes = append(notes, "module "+modPath)
+	}
+	if t := p.lastModified(); !t.IsZero() {
+		notes = append(notes, "modified "+t.Format("2006-01-02 15:04:05"))
+	}
+	if len(notes) == 0 {
+		return
+	}
+	p.PushHighlight(commentGroup)
+	for _, note := range notes {
+		p.WriteString(textIndent)
+		p.WriteString(note)
+		p.WriteString("\n")
+	}
+	p.PopHighlight()
+	p.WriteString("\n")
+}
+
+// lastModified returns the most recent modification time of the package
+// source files. The zero time is returned for packages in the standard
+// library and the module cache.
+func (p *docPrinter) lastModified() time.Time {
+	if p.Build.Goroot {
+		return time.Time{}
+	}
+	for _, root := range filepath.SplitList(p.ctx.GOPATH) {
+		if _, ok := hasSubDir(p.ctx, filepath.Join(root, "pkg", "mod"), p.Build.Dir); ok {
+			return time.Time{}
+		}
+	}
+	var t time.Time
+	for _, fname := range append(p.Build.GoFiles, p.Build.CgoFiles...) {
+		fi, err := os.Stat(filepath.Join(p.Build.Dir, fname))
+		if err == nil && fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t
+}
+
+// printErrors prints the errors encountered while loading the package.
+func (p *docPrinter) printErrors() {
+	if len(p.Errors) == 0 {
+		return
+	}
+	p.PushHighlight(commentGroup)
+	for _, err := range p.Errors {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			p.WriteString(textIndent)
+			p.WriteString(line)
+			p.WriteString("\n")
+		}
+	}
+	p.PopHighlight()
+	p.WriteString("\n")
+}
+
+// printDocText prints the doc comment s if the page detail level includes
+// doc comments.
+func (p *docPrinter) printDocText(s string) {
+	if p.options.Detail >= detailDoc {
+		p.printText(s)
+	}
+}
+
+// overviewFoldLines is the number of lines in a package overview above which
+// the overview after the first paragraph is folded.
+const overviewFoldLines = 40
+
+// printOverview prints the package doc comment s. Long overviews, typically
+// found in packages with a doc.go file, are folded after the first paragraph.
+func (p *docPrinter) printOverview(s string) {
+	if p.options.Detail < detailDoc {
+		return
+	}
+	i := strings.Index(s, "\n\n")
+	if strings.Count(s, "\n") <= overviewFoldLines || i < 0 {
+		p.printText(s)
+		return
+	}
+	p.printText(s[:i])
+	p.PushFold()
+	p.printText(s[i+2:])
+	p.PopFold()
+}
+
 func (p *docPrinter) printText(s string) {
 	s = strings.TrimRight(s, " \t\n")
 	if s != "" {
+		d := p.docParser().Parse(s)
+		links := p.textLinks(d)
+		pr := &comment.Printer{
+			TextPrefix:     textIndent,
+			TextCodePrefix: textIndent + "\t",
+			TextWidth:      textWidth,
+		}
 		p.scratch.Reset()
-		godoc.ToText(&p.scratch, s, textIndent, textIndent+"\t", textWidth)
+		p.scratch.Write(pr.Text(d))
 		blank := 0
-		for _, line := range bytes.Split(p.scratch.Bytes(), []byte{'\n'}) {
+		deprecated := false
+		badge := ""
+		item := 0 // column of the text in the current list item
+		lines := bytes.Split(p.scratch.Bytes(), []byte{'\n'})
+		for i, line := range lines {
+			if len(line) > 0 {
+				line, item = indentListLine(line, item)
+			}
 			if len(line) == 0 {
 				blank++
+				deprecated = false
+			} else if deprecated || bytes.HasPrefix(line, []byte(textIndent+"Deprecated:")) {
+				// Highlight deprecation notes and end the note with a badge
+				// showing the Go version mentioned in the note.
+				for j := 0; j < blank; j++ {
+					p.WriteString("\n")
+				}
+				if !deprecated {
+					badge = deprecatedVersion(lines[i:])
+				}
+				p.PushHighlight(deprecatedGroup)
+				p.writeTextLine(line, links)
+				p.PopHighlight()
+				if badge != "" && (i+1 == len(lines) || len(lines[i+1]) == 0) {
+					p.WriteString(" ")
+					p.PushHighlight(badgeGroup)
+					p.WriteString("[" + badge + "]")
+					p.PopHighlight()
+				}
+				p.WriteString("\n")
+				deprecated = true
+				blank = 0
 			} else {
-				const k = len(textIndent) + 1
-				if blank == 2 && len(line) > k && line[k] != ' ' {
+				for i := 0; i < blank; i++ {
 					p.WriteString("\n")
+				}
+				if (i == 0 || blank > 0) && bytes.HasPrefix(line, []byte(textIndent+"# ")) {
+					// The comment printer marks headings with #. List items
+					// and code are indented past the # column.
+					p.WriteString(textIndent)
 					p.PushHighlight(headerGroup)
-					p.Write(line)
+					p.Write(line[len(textIndent)+2:])
 					p.PopHighlight()
-					p.WriteString("\n")
 				} else {
-					for i := 0; i < blank; i++ {
-						p.WriteString("\n")
-					}
-					p.Write(line)
-					p.WriteString("\n")
+					p.writeTextLine(line, links)
 				}
+				p.WriteString("\n")
 				blank = 0
 			}
 		}
@@ -261,64 +848,366 @@ func (p *docPrinter) printText(s string) {
 	}
 }
 
-var exampleOutputRx = regexp.MustCompile(`(?i)//[[:space:]]*output:`)
+// listMarker returns the column of the text following the list marker at the
+// start of a line printed by the comment printer or 0 if the line does not
+// start a list item. The printer indents list markers past textIndent and
+// uses - for bullets and a number followed by . or ) for numbered items.
+func listMarker(line []byte) int {
+	if !bytes.HasPrefix(line, []byte(textIndent+" ")) {
+		return 0
+	}
+	i := len(textIndent)
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	switch j := i; {
+	case j < len(line) && (line[j] == '-' || line[j] == '*' || line[j] == '+'):
+		i = j + 1
+	default:
+		for j < len(line) && '0' <= line[j] && line[j] <= '9' {
+			j++
+		}
+		if j == i || j == len(line) || (line[j] != '.' && line[j] != ')') {
+			return 0
+		}
+		i = j + 1
+	}
+	if i >= len(line) || line[i] != ' ' {
+		return 0
+	}
+	return i + 1
+}
+
+// indentListLine returns line with the continuation lines of a list item
+// indented to the text of the item. The printer indents continuation lines
+// by a fixed amount, which does not match the text of items with wide
+// numbers. Item is the column of the text in the current item or 0 if the
+// previous line is not in a list item. The new item column is returned.
+func indentListLine(line []byte, item int) ([]byte, int) {
+	if col := listMarker(line); col > 0 {
+		return line, col
+	}
+	const continuation = textIndent + "    "
+	if item == 0 || len(line) <= len(continuation) || !bytes.HasPrefix(line, []byte(continuation)) ||
+		line[len(continuation)] == ' ' || line[len(continuation)] == '\t' {
+		return line, 0
+	}
+	text := line[len(continuation):]
+	return append(bytes.Repeat([]byte{' '}, item), text...), item
+}
+
+var deprecatedVersionPat = regexp.MustCompile(`\bGo 1\.[0-9]+\b`)
+
+// deprecatedVersion returns the Go version mentioned in the deprecation
+// paragraph starting at lines[0] or "" if no version is mentioned.
+func deprecatedVersion(lines [][]byte) string {
+	var para []byte
+	for _, line := range lines {
+		if len(line) == 0 {
+			break
+		}
+		para = append(para, bytes.TrimSpace(line)...)
+		para = append(para, ' ')
+	}
+	return string(deprecatedVersionPat.Find(para))
+}
+
+// docParser returns a doc comment parser that resolves doc links using the
+// current package.
+func (p *docPrinter) docParser() *comment.Parser {
+	if p.pkg != nil && p.GoDoc != nil {
+		return p.GoDoc.Parser()
+	}
+	return new(godoc.Package).Parser()
+}
+
+// textLink is a doc link in printed text.
+type textLink struct {
+	text   string
+	file   string
+	anchor string
+}
+
+// textLinks returns the doc links in a parsed doc comment.
+func (p *docPrinter) textLinks(d *comment.Doc) []*textLink {
+	var links []*textLink
+	var walkText func([]comment.Text)
+	walkText = func(texts []comment.Text) {
+		for _, t := range texts {
+			switch t := t.(type) {
+			case *comment.Link:
+				walkText(t.Text)
+			case *comment.DocLink:
+				var buf bytes.Buffer
+				for _, t := range t.Text {
+					switch t := t.(type) {
+					case comment.Plain:
+						buf.WriteString(string(t))
+					case comment.Italic:
+						buf.WriteString(string(t))
+					}
+				}
+				l := &textLink{text: buf.String(), anchor: t.Name}
+				if t.Recv != "" {
+					l.anchor = t.Recv + "." + t.Name
+				}
+				if t.ImportPath != "" && (p.pkg == nil || t.ImportPath != p.Build.ImportPath) {
+					l.file = bufNamePrefix + t.ImportPath
+				} else {
+					l.file = p.linkPage
+				}
+				if l.text != "" {
+					links = append(links, l)
+				}
+			}
+		}
+	}
+	var walkBlocks func([]comment.Block)
+	walkBlocks = func(blocks []comment.Block) {
+		for _, b := range blocks {
+			switch b := b.(type) {
+			case *comment.Paragraph:
+				walkText(b.Text)
+			case *comment.Heading:
+				walkText(b.Text)
+			case *comment.List:
+				for _, item := range b.Items {
+					walkBlocks(item.Content)
+				}
+			}
+		}
+	}
+	walkBlocks(d.Content)
+	return links
+}
+
+// writeTextLine writes a line of printed text with the doc links in the
+// line written as links.
+func (p *docPrinter) writeTextLine(line []byte, links []*textLink) {
+	for len(line) > 0 {
+		var (
+			first *textLink
+			index = -1
+		)
+		for _, l := range links {
+			if i := indexWord(line, l.text); i >= 0 && (index < 0 || i < index) {
+				first, index = l, i
+			}
+		}
+		if first == nil {
+			break
+		}
+		p.Write(line[:index])
+		p.WriteLinkAnchor(first.text, first.file, first.anchor)
+		line = line[index+len(first.text):]
+	}
+	p.Write(line)
+}
+
+// indexWord returns the index of the first occurrence of s in p that is not
+// adjacent to an identifier character or -1 if there is no such occurrence.
+func indexWord(p []byte, s string) int {
+	offset := 0
+	for {
+		i := bytes.Index(p[offset:], []byte(s))
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		j := i + len(s)
+		if (i == 0 || !isIdentByte(p[i-1])) && (j == len(p) || !isIdentByte(p[j])) {
+			return i
+		}
+		offset = i + 1
+	}
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+var exampleOutputRx = regexp.MustCompile(`(?i)//[[:space:]]*(unordered )?output:`)
 
 func (p *docPrinter) printExamples(name string) {
+	if p.options.Detail < detailExamples || p.options.HideExamples {
+		return
+	}
+	var examples []*godoc.Example
+	var titles []string
 	for _, e := range p.Examples {
 		if !strings.HasPrefix(e.Name, name) {
 			continue
 		}
-		name := e.Name[len(name):]
-		if name != "" {
-			if i := strings.LastIndex(name, "_"); i != 0 {
+		suffix := e.Name[len(name):]
+		title := "Example"
+		if suffix != "" {
+			if i := strings.LastIndex(suffix, "_"); i != 0 {
 				continue
 			}
-			name = name[1:]
-			if r, _ := utf8.DecodeRuneInString(name); unicode.IsUpper(r) {
+			suffix = suffix[1:]
+			if r, _ := utf8.DecodeRuneInString(suffix); unicode.IsUpper(r) {
 				continue
 			}
-			name = strings.Title(name)
-		}
-
-		var node interface{}
-		if _, ok := e.Code.(*ast.File); ok {
-			node = e.Play
-		} else {
-			node = &printer.CommentedNode{Node: e.Code, Comments: e.Comments}
+			title += " (" + strings.Title(suffix) + ")"
 		}
+		examples = append(examples, e)
+		titles = append(titles, title)
+	}
 
-		var buf bytes.Buffer
-		err := (&printer.Config{Tabwidth: 4}).Fprint(&buf, p.FSet, node)
+	// Each example is folded under its title. The examples for a symbol
+	// with more than one example are also folded together.
+	if len(examples) > 1 {
+		p.PushFold()
+	}
+	for i, e := range examples {
+		code, output, err := exampleCode(p.FSet, e)
 		if err != nil {
 			continue
 		}
 
-		// Additional formatting if this is a function body.
-		b := buf.Bytes()
-		if i := len(b); i >= 2 && b[0] == '{' && b[i-1] == '}' {
-			// Remove surrounding braces.
-			b = b[1 : i-1]
-			// Unindent
-			b = bytes.Replace(b, []byte("\n    "), []byte("\n"), -1)
-			// Remove output comment
-			if j := exampleOutputRx.FindIndex(b); j != nil {
-				b = bytes.TrimSpace(b[:j[0]])
+		p.PushFold()
+		p.WriteString(textIndent)
+		p.PushHighlight(headerGroup)
+		p.WriteString(titles[i] + ":")
+		p.PopHighlight()
+		p.WriteString("\n")
+		p.PushCode()
+		p.writeExampleCode(code)
+		p.PopCode(string(code),
+			filepath.Join(p.Build.Dir, p.ExampleFiles[e]),
+			p.FSet.Position(e.Code.Pos()).Line)
+		p.WriteString("\n")
+		p.printExampleOutput(e, output)
+		p.PopFold()
+	}
+	if len(examples) > 1 {
+		p.PopFold()
+	}
+}
+
+// writeExampleCode writes the code of an example indented as a code block.
+// The code is highlighted as a declaration and the comments in the code are
+// highlighted as comments.
+func (p *docPrinter) writeExampleCode(code []byte) {
+	bol := true
+	write := func(b []byte) {
+		for _, line := range bytes.SplitAfter(b, []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
 			}
+			if bol && line[0] != '\n' {
+				p.WriteString(textIndent + "\t")
+			}
+			p.Write(line)
+			bol = line[len(line)-1] == '\n'
+		}
+	}
+
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	base := file.Base()
+	s.Init(file, code, nil, scanner.ScanComments)
+	lastOffset := 0
+	p.PushHighlight(declGroup)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		offset := int(pos) - base
+		write(code[lastOffset:offset])
+		lastOffset = offset + len(lit)
+		p.PushHighlight(commentGroup)
+		write([]byte(lit))
+		p.PopHighlight()
+	}
+	write(code[lastOffset:])
+	p.PopHighlight()
+}
+
+// printExampleOutput prints the expected output of an example. Examples
+// without output or with an empty output comment have no output section.
+func (p *docPrinter) printExampleOutput(e *godoc.Example, output string) {
+	output = strings.TrimRight(output, " \t\n")
+	if output != "" {
+		p.WriteString(textIndent)
+		p.PushHighlight(commentGroup)
+		if e.Unordered {
+			p.WriteString("Unordered output:")
 		} else {
-			// Drop output, as the output comment will appear in the code
-			e.Output = ""
+			p.WriteString("Output:")
+		}
+		p.PopHighlight()
+		p.WriteString("\n")
+		p.PushHighlight(outputGroup)
+		for _, line := range strings.Split(output, "\n") {
+			p.WriteString(textIndent + "\t" + line + "\n")
 		}
+		p.PopHighlight()
+		p.WriteString("\n")
+	}
+	if p.options.ExampleStatus {
+		p.WriteString(textIndent)
+		p.PushHighlight(badgeGroup)
+		p.WriteString(exampleStatus(e))
+		p.PopHighlight()
+		p.WriteString("\n\n")
+	}
+}
 
-		/*
-			p.buf.Write(b)
-			p.buf.WriteByte('\n')
-			if e.Output != "" {
-				p.buf.WriteString(e.Output)
-				buf.WriteByte('\n')
-			}
-			p.buf.WriteByte('\n')
-		*/
+// exampleStatus describes how go test checks the example.
+func exampleStatus(e *godoc.Example) string {
+	switch {
+	case e.EmptyOutput:
+		return "[go test checks that the example prints nothing]"
+	case e.Output != "" && e.Unordered:
+		return "[go test checks the output lines in any order]"
+	case e.Output != "":
+		return "[go test checks the output]"
+	default:
+		return "[go test compiles the example but does not run it]"
+	}
+}
+
+// exampleCode returns the code and output of an example. The code is
+// indented from column zero so that it can be pasted into a Go source file.
+func exampleCode(fset *token.FileSet, e *godoc.Example) ([]byte, string, error) {
+	var node interface{}
+	if _, ok := e.Code.(*ast.File); ok {
+		node = e.Play
+	} else {
+		node = &printer.CommentedNode{Node: e.Code, Comments: e.Comments}
+	}
+
+	var buf bytes.Buffer
+	err := (&printer.Config{Tabwidth: 4}).Fprint(&buf, fset, node)
+	if err != nil {
+		return nil, "", err
 	}
+
+	output := e.Output
+
+	// Additional formatting if this is a function body.
+	b := buf.Bytes()
+	if i := len(b); i >= 2 && b[0] == '{' && b[i-1] == '}' {
+		// Remove surrounding braces.
+		b = b[1 : i-1]
+		// Unindent
+		b = bytes.Replace(b, []byte("\n\t"), []byte("\n"), -1)
+		// Remove output comment
+		if j := exampleOutputRx.FindIndex(b); j != nil {
+			b = b[:j[0]]
+		}
+	} else {
+		// Drop output, as the output comment will appear in the code
+		output = ""
+	}
+	b = bytes.Trim(b, " \t\n")
+	return append(b, '\n'), output, nil
 }
 
 func (p *docPrinter) printFiles(sets ...[]string) {
@@ -355,15 +1244,42 @@ func (p *docPrinter) printFiles(sets ...[]string) {
 
 func (p *docPrinter) printValues(values []*godoc.Value) {
 	for _, d := range values {
-		p.printDecl(d.Decl)
-		p.printText(d.Doc)
+		p.printDecl(d.Decl, d.Doc)
+		p.printDocText(d.Doc)
+		p.printEmbeds(d.Names)
+	}
+}
+
+// printEmbeds prints a note listing the //go:embed patterns for the
+// variables. Patterns naming a file in the package directory link to the
+// file.
+func (p *docPrinter) printEmbeds(names []string) {
+	for _, name := range names {
+		patterns := p.Embeds[name]
+		if len(patterns) == 0 {
+			continue
+		}
+		p.WriteString(textIndent)
+		p.PushHighlight(commentGroup)
+		p.WriteString(name + " embeds:")
+		p.PopHighlight()
+		for _, pattern := range patterns {
+			p.WriteString(" ")
+			fname := filepath.Join(p.Build.Dir, filepath.FromSlash(pattern))
+			if fi, err := os.Stat(fname); err == nil && !fi.IsDir() {
+				p.WriteLink(pattern, fname, 1, 1)
+			} else {
+				p.WriteString(pattern)
+			}
+		}
+		p.WriteString("\n\n")
 	}
 }
 
 func (p *docPrinter) printFuncs(funcs []*godoc.Func, examplePrefix string) {
 	for _, d := range funcs {
-		p.printDecl(d.Decl)
-		p.printText(d.Doc)
+		p.printDecl(d.Decl, d.Doc)
+		p.printDocText(d.Doc)
 		p.printExamples(examplePrefix + d.Name)
 	}
 }
@@ -374,18 +1290,135 @@ func (p *docPrinter) printImports() {
 	}
 	p.printHeader("Imports")
 	for _, imp := range p.Build.Imports {
+		target := imp
+		if imp == "C" {
+			target = cgoImportPath
+		}
 		p.WriteString(textIndent)
-		p.WriteLinkAnchor(imp, bufNamePrefix+imp, "")
+		p.WriteLinkAnchor(imp, bufNamePrefix+target, "")
 		p.WriteString("\n")
 	}
 	p.WriteString("\n")
 }
 
-func (p *docPrinter) printDirs(header string, roots []string) {
+// printNotes prints a section for each marker of the notes collected by
+// go/doc from comments of the form MARKER(uid): body. The sections are
+// sorted by marker.
+func (p *docPrinter) printNotes() {
+	var markers []string
+	for marker := range p.GoDoc.Notes {
+		markers = append(markers, marker)
+	}
+	sort.Strings(markers)
+	for _, marker := range markers {
+		header := marker
+		if marker == "BUG" {
+			header = "Bugs"
+		}
+		p.printNoteSection(header, p.GoDoc.Notes[marker])
+	}
+}
+
+// printNoteSection prints notes under header. Each note is preceded by a
+// link to the note in the source.
+func (p *docPrinter) printNoteSection(header string, notes []*godoc.Note) {
+	if len(notes) == 0 {
+		return
+	}
+	p.printHeader(header)
+	for _, note := range notes {
+		pos := p.FSet.Position(note.Pos)
+		p.WriteString(textIndent)
+		p.PushHighlight(commentGroup)
+		p.WriteLink(fmt.Sprintf("%s:%d", pos.Filename, pos.Line),
+			filepath.Join(p.Build.Dir, pos.Filename),
+			pos.Line, pos.Column)
+		p.PopHighlight()
+		p.WriteString("\n")
+		p.printText(note.Body)
+	}
+}
+
+// printRuntimeNotes prints the paragraphs of the package comments that
+// mention GODEBUG settings.
+func (p *docPrinter) printRuntimeNotes() {
+	var fnames []string
+	for fname := range p.AST.Files {
+		fnames = append(fnames, fname)
+	}
+	sort.Strings(fnames)
+
+	seen := map[string]bool{}
+	header := false
+	for _, fname := range fnames {
+		for _, cg := range p.AST.Files[fname].Comments {
+			text := cg.Text()
+			if !strings.Contains(text, "GODEBUG") {
+				continue
+			}
+			for _, para := range strings.Split(text, "\n\n") {
+				if !strings.Contains(para, "GODEBUG") || seen[para] {
+					continue
+				}
+				seen[para] = true
+				if !header {
+					p.printHeader("Runtime Notes")
+					header = true
+				}
+				pos := p.FSet.Position(cg.Pos())
+				p.WriteString(textIndent)
+				p.PushHighlight(commentGroup)
+				p.WriteLink(fmt.Sprintf("%s:%d", pos.Filename, pos.Line),
+					filepath.Join(p.Build.Dir, pos.Filename),
+					pos.Line, pos.Column)
+				p.PopHighlight()
+				p.WriteString("\n")
+				p.printText(para)
+			}
+		}
+	}
+}
+
+// printStdDirs prints the standard packages on the root page. A note is
+// printed instead if GOROOT does not have a src directory, as for some
+// custom toolchains or a misconfigured GOROOT.
+func (p *docPrinter) printStdDirs() {
+	if _, ok := srcRoot(p.ctx, p.ctx.GOROOT); ok {
+		p.printDirs("Standard Packages", p.ctx.GOROOT, []string{p.ctx.GOROOT})
+		return
+	}
+	p.printHeader("Standard Packages")
+	p.WriteString(textIndent)
+	p.PushHighlight(commentGroup)
+	if p.ctx.GOROOT == "" {
+		p.WriteString("GOROOT is not set.")
+	} else {
+		fmt.Fprintf(p.Doc, "No src directory in GOROOT %s.", p.ctx.GOROOT)
+	}
+	p.PopHighlight()
+	p.WriteString("\n\n")
+}
+
+// srcRoot returns the src directory of a GOROOT or GOPATH root. The result
+// is false if root is empty or the directory does not exist.
+func srcRoot(ctx *build.Context, root string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	dir := buildutil.JoinPath(ctx, root, "src")
+	return dir, buildutil.IsDir(ctx, dir)
+}
+
+// dirNames returns the sorted names of the subdirectories of the current
+// import path in roots. Roots without a src directory are skipped.
+func (p *docPrinter) dirNames(roots []string) []string {
 	m := map[string]bool{}
 	for _, root := range roots {
-		dir := filepath.Join(root, "src", filepath.FromSlash(p.importPath))
-		fis, err := ioutil.ReadDir(dir)
+		src, ok := srcRoot(p.ctx, root)
+		if !ok {
+			continue
+		}
+		fis, err := buildutil.ReadDir(p.ctx, buildutil.JoinPath(p.ctx, src, filepath.FromSlash(p.importPath)))
 		if err != nil {
 			continue
 		}
@@ -396,18 +1429,47 @@ func (p *docPrinter) printDirs(header string, roots []string) {
 			m[fi.Name()] = true
 		}
 	}
-
-	if len(m) == 0 && p.importPath == "" {
-		return
-	}
-
 	var names []string
 	for name := range m {
 		names = append(names, name)
 	}
 	sort.Strings(names)
+	return names
+}
 
-	p.printHeader(header)
+// hasDirs returns true if the current import path has subdirectories in
+// one of the roots.
+func (p *docPrinter) hasDirs(roots []string) bool {
+	for _, root := range roots {
+		if len(p.dirNames([]string{root})) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printDirs prints the subdirectories of the current import path in roots.
+// If header is not "", then the header is printed first. If group is not
+// "", then the directories are printed in a fold below the group name.
+// Roots without a src directory are skipped.
+func (p *docPrinter) printDirs(header string, group string, roots []string) {
+	names := p.dirNames(roots)
+	if len(names) == 0 && p.importPath == "" {
+		return
+	}
+
+	p.markSection(directoriesSection)
+	if header != "" {
+		p.printHeader(header)
+	}
+	if group != "" {
+		p.WriteString(textIndent)
+		p.PushHighlight(commentGroup)
+		p.WriteString(group)
+		p.PopHighlight()
+		p.WriteString("\n")
+		p.PushFold()
+	}
 	if p.importPath != "" {
 		up := path.Dir(p.importPath)
 		if up == "." {
@@ -422,9 +1484,111 @@ func (p *docPrinter) printDirs(header string, roots []string) {
 		p.WriteLinkAnchor(name, bufNamePrefix+path.Join(p.importPath, name), "")
 		p.WriteString("\n")
 	}
+	if group != "" {
+		p.PopFold()
+	}
+	p.WriteString("\n")
+}
+
+// printModuleCache prints the paths of the modules in the module cache in a
+// fold below the cache directory. The modules link to the documentation for
+// the module's root package.
+func (p *docPrinter) printModuleCache() {
+	cache := moduleCacheDir(p.ctx)
+	paths := cachedModulePaths(cache)
+	if len(paths) == 0 {
+		return
+	}
+	p.markSection(directoriesSection)
+	p.printHeader("Module Cache")
+	p.WriteString(textIndent)
+	p.PushHighlight(commentGroup)
+	p.WriteString(cache)
+	p.PopHighlight()
+	p.WriteString("\n")
+	p.PushFold()
+	for _, modPath := range paths {
+		p.WriteString(textIndent)
+		p.WriteLinkAnchor(modPath, bufNamePrefix+modPath, "")
+		p.WriteString("\n")
+	}
+	p.PopFold()
+	p.WriteString("\n")
+}
+
+var licensePatterns = []string{"LICENSE*", "LICENCE*", "COPYING*"}
+
+// printLicense prints links to the license files in the package directory
+// or the closest parent directory containing license files. The link text is
+// the path of the file relative to the package directory.
+func (p *docPrinter) printLicense() {
+	if p.Build == nil || p.Build.Dir == "" {
+		return
+	}
+	var fnames []string
+	for dir := p.Build.Dir; ; {
+		for _, pat := range licensePatterns {
+			m, _ := filepath.Glob(filepath.Join(dir, pat))
+			fnames = append(fnames, m...)
+		}
+		parent := filepath.Dir(dir)
+		if len(fnames) > 0 || dir == p.Build.Root || parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if len(fnames) == 0 {
+		return
+	}
+	sort.Strings(fnames)
+	p.printHeader("License")
+	for _, fname := range fnames {
+		text, err := filepath.Rel(p.Build.Dir, fname)
+		if err != nil {
+			text = fname
+		}
+		p.WriteString(textIndent)
+		p.WriteLinkAnchor(text, fname, "")
+		p.WriteString("\n")
+	}
 	p.WriteString("\n")
 }
 
+// printIndex prints links to the exported symbols in the package. The link
+// text is the name or the one-line signature of the symbol as specified by
+// the Index option.
+func (p *docPrinter) printIndex() {
+	syms := packageSymbols(p.pkg)
+	if len(syms) == 0 {
+		return
+	}
+	p.printHeader("Index")
+	for _, sym := range syms {
+		text := sym.Name
+		if p.options.Index == "signatures" {
+			text = oneLine(sym.Signature, textWidth)
+		}
+		p.WriteString(textIndent)
+		p.WriteLinkAnchor(text, "", sym.Name)
+		p.WriteString("\n")
+	}
+	p.WriteString("\n")
+}
+
+// oneLine returns s with runs of white space replaced by a single space.
+// The result is truncated with "..." to at most width bytes.
+func oneLine(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > width {
+		i := width - len("...")
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		s = s[:i] + "..."
+	}
+	return s
+}
+
 func (p *docPrinter) printHeader(s string) {
 	p.PushHighlight(headerGroup)
 	p.WriteString(strings.ToUpper(s))
@@ -432,11 +1596,24 @@ func (p *docPrinter) printHeader(s string) {
 	p.WriteString("\n\n")
 }
 
-func (p *docPrinter) addAnchor(name, typeName string) {
+// addAnchor adds the anchor for a declared name and records the position of
+// a top-level declaration or method. Constants and variables are
+// also anchored to const.name and var.name so that links can select between a
+// constant and a variable with the same name in files that do not build
+// together.
+func (p *docPrinter) addAnchor(name, typeName string, tok token.Token) {
+	if typeName == "" || tok == token.FUNC {
+		// Fields and interface methods are not declarations for the ]]
+		// and [[ motions.
+		p.Doc.AddDecl()
+	}
 	if typeName != "" {
 		name = typeName + "." + name
 	}
 	p.Doc.AddAnchor(name)
+	if tok == token.CONST || tok == token.VAR {
+		p.Doc.AddAnchor(tok.String() + "." + name)
+	}
 }
 
 const (
@@ -495,6 +1672,23 @@ var predeclared = map[string]int{
 type declVisitor struct {
 	annotations []*annotation
 	comments    []*ast.CommentGroup
+
+	// Limits for displaying literals. Zero specifies no limit.
+	maxElements     int
+	maxStringLength int
+
+	// Elided maps the literals exceeding the limits to the literals
+	// printed in their place.
+	elided map[ast.Node]ast.Node
+}
+
+func (v *declVisitor) elide(n, replacement ast.Node, pos token.Pos, comment string) {
+	if v.elided == nil {
+		v.elided = make(map[ast.Node]ast.Node)
+	}
+	v.elided[n] = replacement
+	v.comments = append(v.comments,
+		&ast.CommentGroup{List: []*ast.Comment{{Slash: pos, Text: comment}}})
 }
 
 func (v *declVisitor) addAnnoation(a *annotation) {
@@ -509,6 +1703,9 @@ func (v *declVisitor) Visit(n ast.Node) ast.Visitor {
 	switch n := n.(type) {
 	case *ast.TypeSpec:
 		v.addAnnoation(&annotation{kind: anchorAnnotation, pos: n.Pos()})
+		if n.TypeParams != nil {
+			ast.Walk(v, n.TypeParams)
+		}
 		name := n.Name.Name
 		switch n := n.Type.(type) {
 		case *ast.InterfaceType:
@@ -532,15 +1729,17 @@ func (v *declVisitor) Visit(n ast.Node) ast.Visitor {
 		if n.Recv == nil {
 			v.addAnnoation(&annotation{kind: anchorAnnotation, pos: n.Name.NamePos})
 		} else {
+			// The receiver type is linked to the type declaration. The
+			// method name is anchored to Type.Method.
 			ast.Walk(v, n.Recv)
+			name := ""
 			if len(n.Recv.List) > 0 {
-				typ := n.Recv.List[0].Type
-				if se, ok := typ.(*ast.StarExpr); ok {
-					typ = se.X
-				}
-				if id, ok := typ.(*ast.Ident); ok {
-					v.addAnnoation(&annotation{kind: anchorAnnotation, data: id.Name, pos: n.Name.NamePos})
-				}
+				name = receiverName(n.Recv.List[0].Type)
+			}
+			if name != "" {
+				v.addAnnoation(&annotation{kind: anchorAnnotation, data: name, pos: n.Name.NamePos})
+			} else {
+				v.ignoreName()
 			}
 		}
 
@@ -575,7 +1774,9 @@ func (v *declVisitor) Visit(n ast.Node) ast.Visitor {
 				if spec, _ := obj.Decl.(*ast.ImportSpec); spec != nil {
 					if path, err := strconv.Unquote(spec.Path.Value); err == nil {
 						if path == "C" {
-							v.ignoreName()
+							// Link the cgo pseudo-package to the cgo
+							// documentation.
+							v.addAnnoation(&annotation{kind: cgoAnnotation})
 							v.ignoreName()
 						} else if n.Sel.Pos()-x.End() == 1 {
 							v.addAnnoation(&annotation{kind: startLinkAnnotation, data: path})
@@ -591,28 +1792,24 @@ func (v *declVisitor) Visit(n ast.Node) ast.Visitor {
 		}
 		ast.Walk(v, n.X)
 		v.ignoreName()
+	case *ast.BadExpr:
+		// The printer prints the text BadExpr.
+		v.ignoreName()
 	case *ast.BasicLit:
-		if n.Kind == token.STRING && len(n.Value) > 128 {
-			v.comments = append(v.comments,
-				&ast.CommentGroup{List: []*ast.Comment{{
-					Slash: n.Pos(),
-					Text:  fmt.Sprintf("/* %d byte string literal not displayed */", len(n.Value)),
-				}}})
-			n.Value = `""`
+		if n.Kind == token.STRING && v.maxStringLength > 0 && len(n.Value) > v.maxStringLength {
+			v.elide(n, &ast.BasicLit{ValuePos: n.ValuePos, Kind: n.Kind, Value: `""`},
+				n.Pos(), fmt.Sprintf("/* %d byte string literal not displayed */", len(n.Value)))
 		} else {
 			return v
 		}
 	case *ast.CompositeLit:
-		if len(n.Elts) > 100 {
+		if v.maxElements > 0 && len(n.Elts) > v.maxElements {
 			if n.Type != nil {
 				ast.Walk(v, n.Type)
 			}
-			v.comments = append(v.comments,
-				&ast.CommentGroup{List: []*ast.Comment{{
-					Slash: n.Lbrace,
-					Text:  fmt.Sprintf("/* %d elements not displayed */", len(n.Elts)),
-				}}})
-			n.Elts = n.Elts[:0]
+			lit := *n
+			lit.Elts = nil
+			v.elide(n, &lit, n.Lbrace, fmt.Sprintf("/* %d elements not displayed */", len(n.Elts)))
 		} else {
 			return v
 		}
@@ -621,3 +1818,75 @@ func (v *declVisitor) Visit(n ast.Node) ast.Visitor {
 	}
 	return nil
 }
+
+var nodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// replaceNodes returns a copy of n with the nodes in m replaced. Only the
+// nodes on the paths from n to the replaced nodes are copied. The other
+// nodes are shared with n.
+func replaceNodes(n ast.Node, m map[ast.Node]ast.Node) ast.Node {
+	v, _ := replaceValue(reflect.ValueOf(n), m)
+	return v.Interface().(ast.Node)
+}
+
+// replaceValue returns a copy of v with the nodes in m replaced and whether
+// v contains a replaced node.
+func replaceValue(v reflect.Value, m map[ast.Node]ast.Node) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		e, changed := replaceValue(v.Elem(), m)
+		if !changed {
+			return v, false
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(e)
+		return c, true
+	case reflect.Ptr:
+		if v.IsNil() || !v.Type().Implements(nodeType) {
+			return v, false
+		}
+		if r, ok := m[v.Interface().(ast.Node)]; ok {
+			return reflect.ValueOf(r), true
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			return v, false
+		}
+		var c reflect.Value
+		for i := 0; i < v.Elem().NumField(); i++ {
+			f, changed := replaceValue(v.Elem().Field(i), m)
+			if !changed {
+				continue
+			}
+			if !c.IsValid() {
+				c = reflect.New(v.Elem().Type())
+				c.Elem().Set(v.Elem())
+			}
+			c.Elem().Field(i).Set(f)
+		}
+		if !c.IsValid() {
+			return v, false
+		}
+		return c, true
+	case reflect.Slice:
+		var c reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			e, changed := replaceValue(v.Index(i), m)
+			if !changed {
+				continue
+			}
+			if !c.IsValid() {
+				c = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+				reflect.Copy(c, v)
+			}
+			c.Index(i).Set(e)
+		}
+		if !c.IsValid() {
+			return v, false
+		}
+		return c, true
+	}
+	return v, false
+}
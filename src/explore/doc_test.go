@@ -5,23 +5,993 @@
 package explore
 
 import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	godoc "go/doc"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/garyburd/vigor/src/context"
+	"github.com/garyburd/vigor/src/doc"
 )
 
 var docTests = []string{
+	bufNamePrefix,
 	bufNamePrefix + "net/http",
+	bufNamePrefix + "./testdata/multi",
+	bufNamePrefix + "./testdata/links",
+	bufNamePrefix + "./testdata/docgo",
 }
 
 func TestDoc(t *testing.T) {
 	ctx := context.Get(&context.Env{})
 	cwd, _ := os.Getwd()
 	for _, tt := range docTests {
-		_, err := printDoc(&ctx.Build, tt, cwd)
+		_, err := printDoc(&ctx.Build, tt, cwd, &docOptions{Detail: detailSource})
 		if err != nil {
 			t.Error(tt, err)
 		}
 	}
 }
+
+var printDocBenchmarks = []struct {
+	name, importPath string
+}{
+	{"small", "./testdata/links"},
+	{"medium", "net/http"},
+	{"large", "syscall"},
+}
+
+func BenchmarkPrintDoc(b *testing.B) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, bm := range printDocBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := printDoc(&ctx.Build, bufNamePrefix+bm.importPath, cwd, &docOptions{Detail: detailExamples}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestTextLinks(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/links", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &docPrinter{pkg: pkg, options: &docOptions{}}
+	links := p.textLinks(p.docParser().Parse(pkg.GoDoc.Doc))
+	want := []textLink{
+		{"Value", "", "Value"},
+		{"fmt.Stringer", bufNamePrefix + "fmt", "Stringer"},
+		{"Value.String", "", "Value.String"},
+		{"strings.Builder.WriteString", bufNamePrefix + "strings", "Builder.WriteString"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(links), len(want))
+	}
+	for i, l := range links {
+		if *l != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, *l, want[i])
+		}
+	}
+}
+
+// checkAnnotations checks that declVisitor creates one annotation for each
+// identifier in the printed declarations of the package.
+func checkAnnotations(t *testing.T, pkg *pkg) {
+	var decls []ast.Decl
+	for _, d := range append(pkg.GoDoc.Consts, pkg.GoDoc.Vars...) {
+		decls = append(decls, d.Decl)
+	}
+	for _, d := range pkg.GoDoc.Funcs {
+		decls = append(decls, d.Decl)
+	}
+	for _, d := range pkg.GoDoc.Types {
+		decls = append(decls, d.Decl)
+		for _, m := range d.Methods {
+			decls = append(decls, m.Decl)
+		}
+	}
+	for _, decl := range decls {
+		v := &declVisitor{}
+		ast.Walk(v, decl)
+		var buf bytes.Buffer
+		if err := (&printer.Config{Tabwidth: 4}).Fprint(&buf, pkg.FSet, &printer.CommentedNode{Node: decl, Comments: v.comments}); err != nil {
+			t.Fatal(err)
+		}
+		var s scanner.Scanner
+		fset := token.NewFileSet()
+		s.Init(fset.AddFile("", fset.Base(), buf.Len()), buf.Bytes(), nil, 0)
+		n := 0
+		for {
+			_, tok, _ := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if tok == token.IDENT {
+				n++
+			}
+		}
+		if n != len(v.annotations) {
+			t.Errorf("%s: %d identifiers, %d annotations", buf.String(), n, len(v.annotations))
+		}
+	}
+}
+
+func TestCgoAnnotations(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	bctx := ctx.Build
+	bctx.CgoEnabled = true
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&bctx, "./testdata/cgo", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.GoDoc.Types) != 3 || len(pkg.GoDoc.Types[0].Funcs) != 1 {
+		t.Fatalf("cgo declarations not documented")
+	}
+	checkAnnotations(t, pkg)
+}
+
+var snippetTests = []string{
+	"// Hello returns a greeting.\nfunc Hello() string { return \"hello\" }\n",
+	"package hello\n\nconst Greeting = \"hello\"\n",
+}
+
+func TestSnippet(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	for _, src := range snippetTests {
+		if _, err := printSnippet(&ctx.Build, []byte(src), &docOptions{}); err != nil {
+			t.Errorf("%q: %v", src, err)
+		}
+	}
+}
+
+func TestSpecSections(t *testing.T) {
+	for tok := token.Token(0); tok < token.TILDE; tok++ {
+		if _, ok := specSections[tok]; tok.IsKeyword() != ok {
+			t.Errorf("%s: keyword %v, section %v", tok, tok.IsKeyword(), ok)
+		}
+	}
+}
+
+var deprecatedVersionTests = []struct {
+	text, version string
+}{
+	{"Deprecated: As of Go 1.16, this function simply calls io.ReadAll.", "Go 1.16"},
+	{"Deprecated: Use Foo instead.", ""},
+	{"Deprecated: this function was deprecated\nin Go 1.20 because of reasons.\n\nGo 1.21 is unrelated.", "Go 1.20"},
+	{"Deprecated: Go 1.100 is not released.", "Go 1.100"},
+}
+
+func TestDeprecatedVersion(t *testing.T) {
+	for _, tt := range deprecatedVersionTests {
+		if v := deprecatedVersion(bytes.Split([]byte(tt.text), []byte{'\n'})); v != tt.version {
+			t.Errorf("deprecatedVersion(%q) = %q, want %q", tt.text, v, tt.version)
+		}
+	}
+}
+
+func TestDocGo(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/docgo", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(pkg.GoDoc.Doc, "Package docgo ") || strings.Count(pkg.GoDoc.Doc, "\n") <= overviewFoldLines {
+		t.Errorf("package doc not loaded from doc.go: %q", pkg.GoDoc.Doc)
+	}
+}
+
+var importStatementTests = []struct {
+	name, importPath, want string
+}{
+	{"http", "net/http", `import "net/http"`},
+	{"yaml", "gopkg.in/yaml.v2", `import "gopkg.in/yaml.v2"`},
+	{"client", "github.com/neovim/go-client", `import "github.com/neovim/go-client"`},
+	{"gocheck", "gopkg.in/check.v1", `import gocheck "gopkg.in/check.v1"`},
+}
+
+func TestImportStatement(t *testing.T) {
+	for _, tt := range importStatementTests {
+		if got := importStatement(tt.name, tt.importPath); got != tt.want {
+			t.Errorf("importStatement(%q, %q) = %s, want %s", tt.name, tt.importPath, got, tt.want)
+		}
+	}
+}
+
+var parseDocNameTests = []struct {
+	name, importPath string
+	funcs            int
+}{
+	{bufNamePrefix + "./testdata/tags", "./testdata/tags", 1},
+	{bufNamePrefix + "./testdata/tags?tags=integration", "./testdata/tags", 2},
+	{bufNamePrefix + "./testdata/tags?tags=foo,integration", "./testdata/tags", 2},
+}
+
+func TestEnvTags(t *testing.T) {
+	defer context.Clear()
+	cwd, _ := os.Getwd()
+	for _, tt := range []struct {
+		tags  []string
+		funcs int
+	}{
+		{nil, 1},
+		{[]string{"integration"}, 2},
+		{nil, 1},
+	} {
+		ctx := context.Get(&context.Env{Tags: tt.tags})
+		pkg, err := loadPackage(&ctx.Build, "./testdata/tags", cwd, loadPackageDoc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pkg.GoDoc.Funcs) != tt.funcs {
+			t.Errorf("tags %v: %d funcs, want %d", tt.tags, len(pkg.GoDoc.Funcs), tt.funcs)
+		}
+	}
+}
+
+func TestParseDocName(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range parseDocNameTests {
+		bctx, importPath := parseDocName(&ctx.Build, tt.name)
+		if importPath != tt.importPath {
+			t.Errorf("parseDocName(%q) import path = %q, want %q", tt.name, importPath, tt.importPath)
+			continue
+		}
+		pkg, err := loadPackage(bctx, importPath, cwd, loadPackageDoc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(pkg.GoDoc.Funcs) != tt.funcs {
+			t.Errorf("%s: %d funcs, want %d", tt.name, len(pkg.GoDoc.Funcs), tt.funcs)
+		}
+	}
+	if len(ctx.Build.BuildTags) != 0 {
+		t.Errorf("build tags %v leaked into the shared context", ctx.Build.BuildTags)
+	}
+}
+
+func TestExampleCode(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/examples", cwd, loadPackageDoc|loadPackageExamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hello *godoc.Example
+	for _, e := range pkg.Examples {
+		if e.Name == "Hello" {
+			hello = e
+		}
+	}
+	if hello == nil {
+		t.Fatal("example Hello not found")
+	}
+	if name := pkg.ExampleFiles[hello]; name != "example_test.go" {
+		t.Errorf("example file = %q, want %q", name, "example_test.go")
+	}
+	code, output, err := exampleCode(pkg.FSet, hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// Print the greeting.\nif s := examples.Hello(); s != \"\" {\n\tfmt.Println(s)\n}\n"
+	if string(code) != want {
+		t.Errorf("code = %q, want %q", code, want)
+	}
+	if output != "hello\n" {
+		t.Errorf("output = %q, want %q", output, "hello\n")
+	}
+}
+
+var exampleOutputTests = []struct {
+	name, output, status string
+}{
+	{"Hello", "    Output:\n    \thello\n\n", "[go test checks the output]"},
+	{"Hello_unordered", "    Unordered output:\n    \ta\n    \tb\n\n", "[go test checks the output lines in any order]"},
+	{"Hello_empty", "", "[go test checks that the example prints nothing]"},
+	{"Hello_notRun", "", "[go test compiles the example but does not run it]"},
+}
+
+func TestExampleOutput(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/examples", cwd, loadPackageDoc|loadPackageExamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples := make(map[string]*godoc.Example)
+	for _, e := range pkg.Examples {
+		examples[e.Name] = e
+	}
+	for _, tt := range exampleOutputTests {
+		e := examples[tt.name]
+		if e == nil {
+			t.Errorf("example %s not found", tt.name)
+			continue
+		}
+		code, output, err := exampleCode(pkg.FSet, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(bytes.ToLower(code), []byte("output:")) {
+			t.Errorf("%s: code contains output comment:\n%s", tt.name, code)
+		}
+		p := &docPrinter{pkg: pkg, Doc: doc.NewDoc(), options: &docOptions{}}
+		p.printExampleOutput(e, output)
+		if got := string(p.Bytes()); got != tt.output {
+			t.Errorf("%s: output section = %q, want %q", tt.name, got, tt.output)
+		}
+		if status := exampleStatus(e); status != tt.status {
+			t.Errorf("%s: status = %q, want %q", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestExampleGroups(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/examples", cwd, &docOptions{Detail: detailExamples})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The titles of the examples in page order, preceded by the declaration
+	// of the symbol they exercise.
+	var titles []string
+	for _, line := range strings.Split(string(d.Bytes()), "\n") {
+		if strings.HasPrefix(line, "func ") || strings.HasPrefix(line, "type ") || strings.HasPrefix(line, textIndent+"Example") {
+			titles = append(titles, strings.TrimSpace(line))
+		}
+	}
+	want := []string{
+		"func Hello() string",
+		"Example:",
+		"Example (Empty):",
+		"Example (NotRun):",
+		"Example (Unordered):",
+		"type Greeter struct{}",
+		"Example:",
+		"Example (Formal):",
+		"func (Greeter) Greet() string",
+		"Example:",
+	}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+}
+
+func TestTypeAliases(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/alias", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAnnotations(t, pkg)
+	want := map[string][]int{
+		"Mode":  {anchorAnnotation, startLinkAnnotation, endLinkAnnotation},
+		"Other": {anchorAnnotation, linkAnnotation},
+		"Value": {anchorAnnotation, linkAnnotation},
+	}
+	for _, d := range pkg.GoDoc.Types {
+		v := &declVisitor{}
+		ast.Walk(v, d.Decl)
+		var kinds []int
+		for _, a := range v.annotations {
+			kinds = append(kinds, a.kind)
+		}
+		if fmt.Sprint(kinds) != fmt.Sprint(want[d.Name]) {
+			t.Errorf("%s: annotations %v, want %v", d.Name, kinds, want[d.Name])
+		}
+		if spec := d.Decl.Specs[0].(*ast.TypeSpec); spec.Assign.IsValid() != (d.Name != "Value") {
+			t.Errorf("%s: alias %v", d.Name, spec.Assign.IsValid())
+		}
+	}
+	if len(pkg.GoDoc.Types[0].Funcs) != 1 {
+		t.Errorf("Perm not grouped with the Mode alias")
+	}
+}
+
+func TestSpecialCharacters(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/special", cwd, &docOptions{Detail: detailSource})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(d.Bytes())
+	for _, want := range []string{
+		"\n" + textIndent + "Compare with a < b && b > c.\n\n",
+		"\n" + textIndent + "Write <b>bold</b> &amp; \"quoted\" text.\n\n",
+		"\n" + textIndent + "Map<K, V> & List<T>\n\n",
+		"\n" + textIndent + "Use List[T] for lists.\n\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text %q not found in\n%s", want, text)
+		}
+	}
+}
+
+func TestTypedConstants(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/enum", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAnnotations(t, pkg)
+	if len(pkg.GoDoc.Consts) != 0 {
+		t.Errorf("%d constant groups not listed with their type", len(pkg.GoDoc.Consts))
+	}
+	want := map[string][]int{
+		"Color":   {anchorAnnotation, linkAnnotation, anchorAnnotation, linkAnnotation},
+		"Weekday": {anchorAnnotation, linkAnnotation, linkAnnotation, anchorAnnotation, anchorAnnotation},
+	}
+	for _, d := range pkg.GoDoc.Types {
+		if len(d.Consts) != 1 {
+			t.Errorf("%s: %d constant groups, want 1", d.Name, len(d.Consts))
+			continue
+		}
+		v := &declVisitor{}
+		ast.Walk(v, d.Consts[0].Decl)
+		var kinds []int
+		for _, a := range v.annotations {
+			kinds = append(kinds, a.kind)
+		}
+		if fmt.Sprint(kinds) != fmt.Sprint(want[d.Name]) {
+			t.Errorf("%s: annotations %v, want %v", d.Name, kinds, want[d.Name])
+		}
+		// The type in the declaration links to the type on the same page.
+		if a := v.annotations[1]; a.data != "" {
+			t.Errorf("%s: type link to package %q", d.Name, a.data)
+		}
+	}
+}
+
+func TestPackageOverview(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printPackageOverview(&ctx.Build, "./testdata/links", cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := string(d.Bytes())
+	if !strings.HasPrefix(p, "package links // import \"./testdata/links\"\n\n    Package links has doc comments") {
+		t.Errorf("overview does not start with the package clause and doc comment:\n%s", p)
+	}
+	for _, s := range []string{"TYPES", "type Value int", "DIRECTORIES"} {
+		if strings.Contains(p, s) {
+			t.Errorf("overview contains %q:\n%s", s, p)
+		}
+	}
+
+	pkg, err := loadPackage(&ctx.Build, "./testdata/links", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := docName(pkg.Build.ImportPath, "")
+	dp := &docPrinter{pkg: pkg, options: &docOptions{}, linkPage: page}
+	links := dp.textLinks(dp.docParser().Parse(pkg.GoDoc.Doc))
+	if len(links) != 4 {
+		t.Fatalf("got %d links, want 4", len(links))
+	}
+	if links[0].file != page || links[1].file != bufNamePrefix+"fmt" {
+		t.Errorf("link files = %q, %q, want %q, %q", links[0].file, links[1].file, page, bufNamePrefix+"fmt")
+	}
+}
+
+func TestReceiverLinks(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/recv", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAnnotations(t, pkg)
+	n := 0
+	for _, d := range pkg.GoDoc.Types {
+		for _, m := range d.Methods {
+			n++
+			v := &declVisitor{}
+			ast.Walk(v, m.Decl)
+			// The receiver type is the first link and the method name is
+			// anchored to the receiver type.
+			var link, anchor *annotation
+			for _, a := range v.annotations {
+				switch {
+				case a.kind == linkAnnotation && link == nil:
+					link = a
+				case a.kind == anchorAnnotation:
+					anchor = a
+				}
+			}
+			if link == nil || link.data != "" {
+				t.Errorf("%s.%s: receiver type %s not linked", d.Name, m.Name, d.Name)
+			}
+			if anchor == nil || anchor.data != d.Name {
+				t.Errorf("%s.%s: method not anchored to %s", d.Name, m.Name, d.Name)
+			}
+		}
+	}
+	if n != 5 {
+		t.Errorf("got %d methods, want 5", n)
+	}
+}
+
+// TestOopsAnnotations tests declarations that printed more identifiers than
+// the declVisitor annotated.
+func TestOopsAnnotations(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/oops", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAnnotations(t, pkg)
+	for _, d := range pkg.GoDoc.Types {
+		for _, m := range d.Methods {
+			v := &declVisitor{}
+			ast.Walk(v, m.Decl)
+			found := false
+			for _, a := range v.annotations {
+				found = found || (a.kind == anchorAnnotation && a.data == d.Name)
+			}
+			if !found {
+				t.Errorf("%s.%s: method not anchored to %s", d.Name, m.Name, d.Name)
+			}
+		}
+	}
+
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/oops", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(d.Bytes())
+	for _, want := range []string{
+		"var X, Y = 1 + BadExpr\n",
+		"func (t *(T)) M(x int) T\n",
+		"func (t T) N() (Result T)\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("declaration %q not found in page:\n%s", want, page)
+		}
+	}
+}
+
+func TestMissingGOROOT(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "vigor-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	if err := os.MkdirAll(filepath.Join(gopath, "src", "example.com"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ goroot, note string }{
+		{"", "GOROOT is not set."},
+		{gopath + "/missing", "No src directory in GOROOT " + gopath + "/missing."},
+	} {
+		ctx := context.Get(&context.Env{}).Build
+		ctx.GOROOT = tt.goroot
+		ctx.GOPATH = gopath
+		d, err := printDoc(&ctx, bufNamePrefix, gopath, &docOptions{})
+		if err != nil {
+			t.Errorf("GOROOT=%q: %v", tt.goroot, err)
+			continue
+		}
+		p := string(d.Bytes())
+		if !strings.Contains(p, "STANDARD PACKAGES\n\n"+textIndent+tt.note+"\n") {
+			t.Errorf("GOROOT=%q: note %q not found in page:\n%s", tt.goroot, tt.note, p)
+		}
+		if !strings.Contains(p, textIndent+"example.com\n") {
+			t.Errorf("GOROOT=%q: GOPATH packages not found in page:\n%s", tt.goroot, p)
+		}
+	}
+}
+
+func TestRootPageGroups(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "vigor-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	gopath1 := filepath.Join(tmp, "a")
+	gopath2 := filepath.Join(tmp, "b")
+	for _, dir := range []string{
+		filepath.Join(gopath1, "src", "example.com"),
+		filepath.Join(gopath2, "src", "example.org"),
+		filepath.Join(gopath1, "pkg", "mod", "cache", "download", "example.net"),
+		filepath.Join(gopath1, "pkg", "mod", "github.com", "!foo", "bar@v1.0.0"),
+		filepath.Join(gopath1, "pkg", "mod", "github.com", "!foo", "bar@v1.1.0"),
+	} {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOMODCACHE", "")
+
+	ctx := context.Get(&context.Env{}).Build
+	ctx.GOPATH = gopath1 + string(filepath.ListSeparator) + gopath2
+	d, err := printDoc(&ctx, bufNamePrefix, tmp, &docOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := string(d.Bytes())
+	if n := strings.Count(p, "THIRD PARTY PACKAGES"); n != 1 {
+		t.Errorf("third party header found %d times, want 1, in page:\n%s", n, p)
+	}
+	for _, s := range []string{
+		textIndent + gopath1 + "\n" + textIndent + "example.com\n",
+		textIndent + gopath2 + "\n" + textIndent + "example.org\n",
+		"MODULE CACHE\n\n" + textIndent + filepath.Join(gopath1, "pkg", "mod") + "\n" + textIndent + "github.com/Foo/bar\n\n",
+	} {
+		if !strings.Contains(p, s) {
+			t.Errorf("%q not found in page:\n%s", s, p)
+		}
+	}
+}
+
+func TestLicenseLinks(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "vigor-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com", "m")
+	if err := os.MkdirAll(filepath.Join(dir, "p"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for fname, data := range map[string]string{
+		filepath.Join(dir, "LICENSE"):   "license\n",
+		filepath.Join(dir, "p", "p.go"): "package p\n",
+	} {
+		if err := ioutil.WriteFile(fname, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Get(&context.Env{}).Build
+	ctx.GOPATH = gopath
+	d, err := printDoc(&ctx, bufNamePrefix+"example.com/m/p", gopath, &docOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := string(d.Bytes())
+	want := "LICENSE\n\n" + textIndent + filepath.Join("..", "LICENSE") + "\n"
+	if !strings.Contains(p, want) {
+		t.Errorf("%q not found in page:\n%s", want, p)
+	}
+	if strings.Contains(p, dir) {
+		t.Errorf("absolute path %s found in page:\n%s", dir, p)
+	}
+}
+
+var indexTests = []struct {
+	index string
+	want  string
+}{
+	{"names", "INDEX\n\n    Value\n    Value.String\n\n"},
+	{"signatures", "INDEX\n\n    type Value int\n    func (v Value) String() string\n\n"},
+}
+
+func TestIndex(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range indexTests {
+		d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/links", cwd, &docOptions{Detail: detailDoc, Index: tt.index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := string(d.Bytes()); !strings.Contains(p, tt.want) {
+			t.Errorf("index %q not found in page:\n%s", tt.want, p)
+		}
+	}
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/links", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := string(d.Bytes()); strings.Contains(p, "INDEX") {
+		t.Errorf("index found in page without the option:\n%s", p)
+	}
+}
+
+var oneLineTests = []struct {
+	s     string
+	width int
+	want  string
+}{
+	{"func F(a int,\n\tb int)", 20, "func F(a int, b int)"},
+	{"func Long(parameter string) string", 17, "func Long(para..."},
+	{"func Fé(x string)", 10, "func F..."},
+}
+
+func TestOneLine(t *testing.T) {
+	for _, tt := range oneLineTests {
+		if got := oneLine(tt.s, tt.width); got != tt.want {
+			t.Errorf("oneLine(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestAnchorCollision(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/collide", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(d.Bytes()), "\n")
+	for _, tt := range []struct{ anchor, line string }{
+		{"X", "const X = 1"},
+		{"const.X", "const X = 1"},
+		{"var.X", "var X = 2"},
+	} {
+		line, _, ok := d.Anchor(tt.anchor)
+		if !ok {
+			t.Errorf("anchor %s not found", tt.anchor)
+			continue
+		}
+		if got := lines[line-1]; got != tt.line {
+			t.Errorf("anchor %s at %q, want %q", tt.anchor, got, tt.line)
+		}
+	}
+}
+
+func TestSections(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"net", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, _ := d.Var("vigor_sections").(map[string]int)
+	lines := strings.Split(string(d.Bytes()), "\n")
+	for _, tt := range []struct{ name, prefix string }{
+		{packageSection, "package net"},
+		{directoriesSection, "DIRECTORIES"},
+	} {
+		line, ok := sections[tt.name]
+		if !ok {
+			t.Errorf("section %s not found", tt.name)
+			continue
+		}
+		if got := lines[line-1]; !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("section %s at %q, want prefix %q", tt.name, got, tt.prefix)
+		}
+	}
+}
+
+func TestLists(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/lists", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := map[string]bool{}
+	for _, line := range strings.Split(string(d.Bytes()), "\n") {
+		lines[line] = true
+	}
+	for _, line := range []string{
+		"    Options",
+		"      - Fast selects the fast path.",
+		"        not available on the platform.",
+		"     1. Open the file.",
+		"     9. nine",
+		"     10. ten is the last step and the description of the step is long enough to",
+		"         wrap.",
+	} {
+		if !lines[line] {
+			t.Errorf("line %q not found", line)
+		}
+	}
+	if lines["    # Options"] {
+		t.Error("heading printed with # marker")
+	}
+}
+
+func TestListMarker(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		col  int
+	}{
+		{"      - item", 8},
+		{"     1. item", 8},
+		{"     10. item", 9},
+		{"     1) item", 8},
+		{"    - paragraph", 0},
+		{"      -flag", 0},
+		{"      12 items", 0},
+		{"    \tcode", 0},
+	} {
+		if col := listMarker([]byte(tt.line)); col != tt.col {
+			t.Errorf("listMarker(%q) = %d, want %d", tt.line, col, tt.col)
+		}
+	}
+}
+
+func TestHideExamples(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, hide := range []bool{false, true} {
+		d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/examples", cwd, &docOptions{Detail: detailExamples, HideExamples: hide})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(d.Bytes()), "Example:"); got == hide {
+			t.Errorf("HideExamples=%v, examples shown = %v", hide, got)
+		}
+	}
+}
+
+func TestFoldDecl(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"net/http", cwd, &docOptions{Detail: detailDoc, FoldDeclLines: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(d.Bytes()), "\n")
+	for _, tt := range []struct {
+		decl   string
+		folded bool
+	}{
+		{"type Request struct {", true},
+		{"type Handler interface {", false},
+	} {
+		line := 0
+		for i, l := range lines {
+			if l == tt.decl {
+				line = i + 1
+				break
+			}
+		}
+		if line == 0 {
+			t.Errorf("%q not found", tt.decl)
+			continue
+		}
+		if _, _, ok := d.Fold(line); ok {
+			t.Errorf("%q folded, want first line visible", tt.decl)
+		}
+		start, _, ok := d.Fold(line + 1)
+		if folded := ok && start == line+1; folded != tt.folded {
+			t.Errorf("%q folded = %v, want %v", tt.decl, folded, tt.folded)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/notes", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(d.Bytes())
+	for _, want := range []string{
+		"BUGS\n\n    notes.go:4\n    Read does not handle short reads.\n\n    notes.go:9\n    Write is slow.\n\nDEPRECATED\n\n",
+		"DEPRECATED\n\n    notes.go:19\n    Older is replaced by Read.\n\nTODO\n\n",
+		"TODO\n\n    notes.go:12\n    Add Close.\n",
+		"func Old() [deprecated]\n",
+		"type Older struct { [deprecated]\n",
+		"func Read()\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("%q not found in\n%s", want, s)
+		}
+	}
+}
+
+func TestDecls(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/decls", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(d.Bytes()), "\n")
+	var got []string
+	for _, decl := range d.Decls() {
+		line := lines[decl[0]-1]
+		got = append(got, line[:decl[1]-1]+"|"+line[decl[1]-1:])
+	}
+	want := []string{
+		"const |Max = 10",
+		"var |Default = New()",
+		"type |Getter interface {",
+		"type |Value struct {",
+		"func |New() *Value",
+		"func (v *Value) |Get() int",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decls = %q, want %q", got, want)
+	}
+}
+
+func TestLiteralHighlights(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/literals", cwd, &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Highlighted(numberGroup), []string{"1", "10", "1.5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("numbers = %q, want %q", got, want)
+	}
+	var strs []string
+	for _, s := range d.Highlighted(stringGroup) {
+		if s != "" {
+			strs = append(strs, s)
+		}
+	}
+	if want := []string{`"gopher"`, `'\t'`, "`a", "b`", `"old"`}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("strings = %q, want %q", strs, want)
+	}
+	if got := d.Highlighted(commentGroup); len(got) == 0 || got[len(got)-1] != "// The name." {
+		t.Errorf("comments = %q, want last comment %q", got, "// The name.")
+	}
+}
+
+func TestRenderCachedPackage(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	render := func(full bool) string {
+		d, err := printDoc(&ctx.Build, bufNamePrefix+"./testdata/elide", cwd, &docOptions{
+			Detail: detailSource, MaxElements: 3, MaxStringLength: 10, Full: full})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(d.Bytes())
+	}
+
+	clearPackageCache()
+	want := render(false)
+	clearPackageCache()
+	wantFull := render(true)
+	clearPackageCache()
+	defer clearPackageCache()
+
+	if !strings.Contains(want, "not displayed") || strings.Contains(wantFull, "not displayed") {
+		t.Fatalf("elided literals not rendered as expected:\n%s\n%s", want, wantFull)
+	}
+
+	// Render the cached package concurrently as the RPC handlers do.
+	var wg sync.WaitGroup
+	got := make([]string, 4)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = render(i%2 == 1)
+		}(i)
+	}
+	wg.Wait()
+	got = append(got, render(false), render(true))
+	for i, g := range got {
+		w := want
+		if i%2 == 1 {
+			w = wantFull
+		}
+		if g != w {
+			t.Errorf("render %d of cached package =\n%s\nwant\n%s", i, g, w)
+		}
+	}
+}
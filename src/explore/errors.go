@@ -0,0 +1,97 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"os"
+
+	"github.com/garyburd/vigor/src/doc"
+)
+
+// Kinds of errors returned when resolving packages and symbols.
+const (
+	errorOther = iota
+	errorPackageNotFound
+	errorSymbolNotFound
+	errorBuild
+	errorParse
+)
+
+var errorTitles = map[int]string{
+	errorOther:           "Error",
+	errorPackageNotFound: "Package not found",
+	errorSymbolNotFound:  "Symbol not found",
+	errorBuild:           "Build error",
+	errorParse:           "Parse error",
+}
+
+// resolveError is an error with a kind so that callers can tailor the
+// presentation of the error.
+type resolveError struct {
+	kind int
+	err  error
+}
+
+func (e *resolveError) Error() string { return e.err.Error() }
+func (e *resolveError) Unwrap() error { return e.err }
+
+// newError returns an error of the given kind formatted with fmt.Errorf.
+func newError(kind int, format string, args ...interface{}) error {
+	return &resolveError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// errorKind returns the kind of err or errorOther if err does not have a
+// kind.
+func errorKind(err error) int {
+	var e *resolveError
+	if errors.As(err, &e) {
+		return e.kind
+	}
+	return errorOther
+}
+
+// importError returns the error from importing bpkg with a kind. An error
+// for a package directory that does not exist is package not found. Other
+// errors are build errors.
+func importError(bpkg *build.Package, err error) error {
+	if errorKind(err) != errorOther {
+		return err
+	}
+	if bpkg == nil || bpkg.Dir == "" {
+		return &resolveError{kind: errorPackageNotFound, err: err}
+	}
+	if fi, serr := os.Stat(bpkg.Dir); serr != nil || !fi.IsDir() {
+		return &resolveError{kind: errorPackageNotFound, err: err}
+	}
+	return &resolveError{kind: errorBuild, err: err}
+}
+
+// defError returns the error reported to the user for an error from
+// findDef.
+func defError(err error) error {
+	switch errorKind(err) {
+	case errorPackageNotFound:
+		return err
+	case errorSymbolNotFound:
+		return fmt.Errorf("definition not found: %v", err)
+	case errorBuild, errorParse:
+		return fmt.Errorf("cannot load package: %v", err)
+	}
+	return errors.New("definition not found")
+}
+
+// printError prints a page for an error loading a documentation page.
+func printError(err error) *doc.Doc {
+	d := doc.NewDoc()
+	d.PushHighlight(headerGroup)
+	d.WriteString(errorTitles[errorKind(err)])
+	d.PopHighlight()
+	d.WriteString("\n\n")
+	d.WriteString(textIndent + err.Error() + "\n")
+	return d
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+var loadErrorTests = []struct {
+	importPath string
+	kind       int
+}{
+	{"example.com/missing", errorPackageNotFound},
+	{"./testdata/missing", errorPackageNotFound},
+}
+
+func TestLoadErrorKind(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range loadErrorTests {
+		_, err := loadPackage(&ctx.Build, tt.importPath, cwd, loadPackageDoc)
+		if err == nil {
+			t.Errorf("loadPackage(%q) returned nil error", tt.importPath)
+			continue
+		}
+		if kind := errorKind(err); kind != tt.kind {
+			t.Errorf("loadPackage(%q) error kind = %s, want %s (%v)", tt.importPath, errorTitles[kind], errorTitles[tt.kind], err)
+		}
+	}
+}
+
+// TestBestEffortBuild tests that directories that do not build are
+// documented from the files in the directory with a note.
+func TestBestEffortBuild(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	for _, tt := range []struct{ importPath, name string }{
+		{"./testdata/badbuild", "badbuild"},
+		{"./testdata/excluded", "main"},
+	} {
+		pkg, err := loadPackage(&ctx.Build, tt.importPath, cwd, loadPackageDoc)
+		if err != nil {
+			t.Errorf("loadPackage(%q) returned error %v", tt.importPath, err)
+			continue
+		}
+		if pkg.GoDoc == nil || pkg.GoDoc.Name != tt.name {
+			t.Errorf("loadPackage(%q) did not document package %s", tt.importPath, tt.name)
+		}
+		if len(pkg.Errors) != 1 || errorKind(pkg.Errors[0]) != errorBuild {
+			t.Errorf("loadPackage(%q) errors = %v, want one build error", tt.importPath, pkg.Errors)
+		}
+	}
+}
+
+func TestFindDefErrorKind(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	_, _, _, err := findDef(&ctx.Build, cwd, "./testdata/embed", "Missing")
+	if kind := errorKind(err); kind != errorSymbolNotFound {
+		t.Errorf("findDef error kind = %s, want %s (%v)", errorTitles[kind], errorTitles[errorSymbolNotFound], err)
+	}
+	_, _, err = returnType(&ctx.Build, cwd, "./testdata/embed", "Missing")
+	if kind := errorKind(err); kind != errorSymbolNotFound {
+		t.Errorf("returnType error kind = %s, want %s (%v)", errorTitles[kind], errorTitles[errorSymbolNotFound], err)
+	}
+}
+
+func TestParseErrorKind(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/syntax", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.Errors) != 1 || errorKind(pkg.Errors[0]) != errorParse {
+		t.Errorf("errors = %v, want one parse error", pkg.Errors)
+	}
+}
+
+func TestPrintError(t *testing.T) {
+	err := newError(errorSymbolNotFound, "Missing not found in example.com/p")
+	got := string(printError(err).Bytes())
+	want := "Symbol not found\n\n    Missing not found in example.com/p\n"
+	if got != want {
+		t.Errorf("printError() = %q, want %q", got, want)
+	}
+}
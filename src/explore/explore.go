@@ -6,12 +6,20 @@
 package explore
 
 import (
+	"bytes"
+	"debug/buildinfo"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/garyburd/vigor/src/context"
 	"github.com/garyburd/vigor/src/doc"
+	"github.com/garyburd/vigor/src/quickfix"
 	"github.com/neovim/go-client/nvim"
 	"github.com/neovim/go-client/nvim/plugin"
 )
@@ -20,15 +28,49 @@ func Register(p *plugin.Plugin) {
 	e := &explorer{docm: doc.NewManager(p), nvim: p.Nvim}
 	p.HandleCommand(&plugin.CommandOptions{Name: "Godoc", NArgs: "*", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onDoc)
 	p.HandleCommand(&plugin.CommandOptions{Name: "Godef", NArgs: "*", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onDef)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Goref", NArgs: "*", Bang: true, Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onRef)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Goimpl", NArgs: "*", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onImpl)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godoccall", Eval: "*"}, e.onDocCall)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocbuffers"}, e.onDocBuffers)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocbin", NArgs: "1", Complete: "file", Eval: "*"}, e.onDocBin)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocreturn", Eval: "*"}, e.onDocReturn)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gowhatis", Eval: "*"}, e.onWhatis)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocgrep", NArgs: "1", Eval: "*"}, e.onDocGrep)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocuse", Eval: "*"}, e.onDocUse)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocexamples", Eval: "*"}, e.onDocExamples)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocsym", NArgs: "1", Eval: "*"}, e.onDocSym)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocsymnext", Eval: "*"}, e.onDocSymNext)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocsymprev", Eval: "*"}, e.onDocSymPrev)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocoverview", NArgs: "1", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onDocOverview)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Godocsnippet", Range: "%", Eval: "*"}, e.onDocSnippet)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gosigdiff", NArgs: "*", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onSigDiff)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gosymbols", NArgs: "1", Complete: "customlist,QQQDocComplete", Eval: "*"}, e.onSymbols)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Vigorconfig", Eval: "*"}, e.onConfig)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Vigorreset"}, e.onReset)
 	p.HandleFunction(&plugin.FunctionOptions{Name: "QQQDocComplete", Eval: "*"}, e.onComplete)
+	p.HandleFunction(&plugin.FunctionOptions{Name: "VigorSymbolIndex", Eval: "*"}, e.onSymbolIndex)
+	p.HandleFunction(&plugin.FunctionOptions{Name: "VigorBufferLabel", Eval: "*"}, e.onBufferLabel)
 	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufReadCmd", Pattern: bufNamePrefix + "**", Eval: "*"}, e.onBufReadCmd)
 }
 
 type explorer struct {
 	nvim *nvim.Nvim
 	docm *doc.Manager
+
+	// The packages exporting the symbol from the last :Godocsym and the
+	// index of the package shown.
+	mu       sync.Mutex
+	symName  string
+	symPaths []string
+	symIndex int
+
+	// panel is the side panel window for documentation pages when
+	// g:vigor_panel is set.
+	panel nvim.Window
 }
 
+// expandSpec expands the |cmdline-special| characters at the start of spec.
+// An error is returned if the expansion is empty.
 func (e *explorer) expandSpec(spec string) (string, error) {
 	if len(spec) == 0 {
 		return spec, nil
@@ -36,36 +78,266 @@ func (e *explorer) expandSpec(spec string) (string, error) {
 	if spec[0] != '%' && spec[0] != '#' && spec[0] != '<' {
 		return spec, nil
 	}
-	err := e.nvim.Call("expand", &spec, spec)
-	return spec, err
+	var expanded string
+	if err := e.nvim.Call("expand", &expanded, spec); err != nil {
+		return "", fmt.Errorf("cannot expand %s: %v", spec, err)
+	}
+	lspec := strings.ToLower(spec)
+	if strings.HasPrefix(lspec, "<cword>") {
+		// <cWORD> includes punctuation surrounding the word.
+		expanded = strings.Trim(expanded, "()[]{}<>,;:*&!\"'`")
+	}
+	if expanded != "" {
+		return expanded, nil
+	}
+	switch {
+	case strings.HasPrefix(lspec, "<cword>"):
+		return "", errors.New("no symbol under cursor")
+	case strings.HasPrefix(lspec, "<cfile>"):
+		return "", errors.New("no file name under cursor")
+	case spec[0] == '%':
+		return "", errors.New("no file name for current buffer")
+	case spec[0] == '#':
+		return "", errors.New("no alternate file name")
+	default:
+		return "", fmt.Errorf("%s expands to empty string", spec)
+	}
 }
 
 func (e *explorer) onDoc(args []string, eval *struct {
 	Env   context.Env
 	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
 	Name  string `eval:"expand('%')"`
 	Bufnr int    `eval:"bufnr('%')"`
+
+	// DefaultPackage is the package specification used when no arguments
+	// are given and the current buffer is not a Go file.
+	DefaultPackage string `eval:"get(g:, 'vigor_default_package', '.')"`
+
+	Panel panelOptions
 }) error {
 
-	if len(args) < 1 || len(args) > 2 {
-		return errors.New("one or two arguments required")
+	var (
+		setup []string
+		index int
+		tags  string
+	)
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch {
+		case args[0] == "-full":
+			setup = append(setup, "let b:vigor_full = 1")
+		case args[0] == "-tags":
+			if len(args) < 2 {
+				return errors.New("-tags requires a comma separated list of tags")
+			}
+			tags = args[1]
+			args = args[1:]
+		case strings.HasPrefix(args[0], "-tags="):
+			tags = args[0][len("-tags="):]
+		case isIndexFlag(args[0]):
+			index, _ = strconv.Atoi(args[0][1:])
+		default:
+			return fmt.Errorf("unknown option %s", args[0])
+		}
+		args = args[1:]
 	}
 
-	spec, err := e.expandSpec(args[0])
+	if len(args) > 3 {
+		return errors.New("at most three arguments allowed")
+	}
+
+	spec := eval.DefaultPackage
+	var err error
+	switch {
+	case len(args) > 0:
+		spec, err = e.expandSpec(args[0])
+		if err != nil {
+			return err
+		}
+	case strings.HasSuffix(eval.Name, ".go"):
+		// Document the package of the current file.
+		spec = eval.Name
+	}
+
+	ctx := context.Get(&eval.Env)
+	bctx := &ctx.Build
+	if tags != "" {
+		bctx = withTags(bctx, tags)
+	}
+	path := resolvePackageSpec(bctx, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+
+	if strings.Contains(path, "...") {
+		path, err = e.choose("Select package:", matchPackages(bctx, path), index)
+		if err != nil || path == "" {
+			return err
+		}
+	}
+
+	var sym string
+	if fname, line, ok := fileLine(spec); ok && len(args) == 1 {
+		// Jump to the declaration at the line in the file.
+		sym, err = declAtLine(bctx, eval.Cwd, path, fname, line)
+		if err != nil {
+			return err
+		}
+	} else if len(args) >= 2 {
+		sym, err = e.expandSpec(args[1])
+		if err != nil {
+			return err
+		}
+		if len(args) == 3 {
+			// The third argument is a method or field of the type.
+			sym = strings.Trim(sym, ".") + "." + args[2]
+		}
+		sym, err = e.choose("Select symbol:", matchSymbols(bctx, path, strings.Trim(sym, ".")), index)
+		if err != nil || sym == "" {
+			return err
+		}
+	}
+	curName, err := e.usePanel(&eval.Panel, eval.Name)
 	if err != nil {
 		return err
 	}
+	return e.openDoc(curName, docName(path, tags), sym, setup...)
+}
 
-	ctx := context.Get(&eval.Env)
-	name := bufNamePrefix + resolvePackageSpec(&ctx.Build, eval.Cwd, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+// isIndexFlag returns true if flag has the form -N where N is a number.
+func isIndexFlag(flag string) bool {
+	if len(flag) < 2 {
+		return false
+	}
+	for _, c := range flag[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// choose returns the item selected by the 1-based index or, if index is
+// zero, the item selected by the user from a list. Ambiguity is only
+// resolved if there is more than one item. The empty string is returned if
+// the user cancels the selection.
+func (e *explorer) choose(prompt string, items []string, index int) (string, error) {
+	switch {
+	case len(items) == 0:
+		return "", errors.New("no matches found")
+	case len(items) == 1:
+		return items[0], nil
+	case index > 0:
+		if index > len(items) {
+			return "", fmt.Errorf("%d matches found", len(items))
+		}
+		return items[index-1], nil
+	}
+	lines := []string{prompt}
+	for i, item := range items {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, item))
+	}
+	var n int
+	if err := e.nvim.Call("inputlist", &n, lines); err != nil {
+		return "", err
+	}
+	if n < 1 || n > len(items) {
+		return "", nil
+	}
+	return items[n-1], nil
+}
+
+// docFlags are the options accepted by :Godoc.
+var docFlags = []string{"-full", "-tags"}
+
+// panelOptions specifies the side panel used for documentation pages.
+type panelOptions struct {
+	// Position is the side of the screen for the panel: "left", "right",
+	// "top" or "bottom". The panel is not used if the position is "".
+	Position string `eval:"get(g:, 'vigor_panel', '')"`
+
+	// Size is the width of a left or right panel or the height of a top or
+	// bottom panel. Zero specifies half of the screen.
+	Size int `eval:"get(g:, 'vigor_panel_size', 0)"`
+}
+
+// panelSplits are the commands for opening a panel at each position.
+var panelSplits = map[string]string{
+	"left":   "topleft vertical %snew",
+	"right":  "botright vertical %snew",
+	"top":    "topleft %snew",
+	"bottom": "botright %snew",
+}
+
+// usePanel makes the side panel the current window, opening the panel if
+// needed, and returns the name of the buffer in the panel. If the panel is
+// not enabled, then the current window is used and curName is returned.
+func (e *explorer) usePanel(options *panelOptions, curName string) (string, error) {
+	if options.Position == "" {
+		return curName, nil
+	}
+	split, ok := panelSplits[options.Position]
+	if !ok {
+		return "", fmt.Errorf("invalid g:vigor_panel %q", options.Position)
+	}
+
+	e.mu.Lock()
+	panel := e.panel
+	e.mu.Unlock()
+
+	if panel != 0 {
+		if valid, err := e.nvim.IsWindowValid(panel); err != nil {
+			return "", err
+		} else if valid {
+			if err := e.nvim.SetCurrentWindow(panel); err != nil {
+				return "", err
+			}
+			buf, err := e.nvim.WindowBuffer(panel)
+			if err != nil {
+				return "", err
+			}
+			return e.nvim.BufferName(buf)
+		}
+	}
+
+	size := ""
+	if options.Size > 0 {
+		size = strconv.Itoa(options.Size)
+	}
+	if err := e.nvim.Command(fmt.Sprintf(split, size)); err != nil {
+		return "", err
+	}
+	panel, err := e.nvim.CurrentWindow()
+	if err != nil {
+		return "", err
+	}
+	e.mu.Lock()
+	e.panel = panel
+	e.mu.Unlock()
+	return "", nil
+}
+
+// openDoc opens the documentation page with the given name and moves the
+// cursor to the anchor for sym. The current buffer is reused if its name is
+// curName. If setup commands are specified, then the commands are executed in
+// the documentation buffer and the page is rendered again.
+func (e *explorer) openDoc(curName string, name string, sym string, setup ...string) error {
 
 	var cmds []string
-	if name != eval.Name {
+	if name != curName {
 		cmds = append(cmds, "edit "+name)
 	}
 
-	if len(args) >= 2 {
-		cmds = append(cmds, fmt.Sprintf("call cursor(get(b:anchors, %q, [0, 0]))", strings.Trim(args[1], ".")))
+	if len(setup) > 0 {
+		cmds = append(cmds, setup...)
+		cmds = append(cmds, doc.ReloadCmd)
+	}
+
+	if sym != "" {
+		// Fields do not have anchors. Use the anchor for the type instead.
+		typ := sym
+		if i := strings.Index(sym, "."); i >= 0 {
+			typ = sym[:i]
+		}
+		cmds = append(cmds, fmt.Sprintf("call cursor(get(b:anchors, %q, get(b:anchors, %q, [0, 0])))", sym, typ))
 	}
 	if len(cmds) == 0 {
 		return nil
@@ -73,13 +345,288 @@ func (e *explorer) onDoc(args []string, eval *struct {
 	return e.nvim.Command(strings.Join(cmds, " | "))
 }
 
+func (e *explorer) onDocCall(eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Name  string `eval:"expand('%:p')"`
+	Bufnr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
+	Panel panelOptions
+}) error {
+	sf, err := parseSource(nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)))
+	if err != nil {
+		return err
+	}
+	name, sym, err := sf.callTarget(eval.Line, eval.Col)
+	if err != nil {
+		return err
+	}
+	ctx := context.Get(&eval.Env)
+	var path string
+	if name == "" {
+		path = resolvePackageSpec(&ctx.Build, eval.Cwd, "", nil, eval.Name)
+	} else {
+		path = sf.imports[name]
+	}
+	curName, err := e.usePanel(&eval.Panel, "")
+	if err != nil {
+		return err
+	}
+	return e.openDoc(curName, docName(path, ""), sym)
+}
+
+// onWhatis echoes the kind, package and declaration of the identifier at
+// the cursor in a Go source buffer.
+func (e *explorer) onWhatis(eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Name  string `eval:"expand('%:p')"`
+	Bufnr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
+}) error {
+	sf, err := parseSource(nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)))
+	if err != nil {
+		return err
+	}
+	name, sym, err := sf.identTarget(eval.Line, eval.Col)
+	if err != nil {
+		return err
+	}
+	ctx := context.Get(&eval.Env)
+	var path string
+	switch name {
+	case "":
+		path = resolvePackageSpec(&ctx.Build, eval.Cwd, "", nil, eval.Name)
+	case "builtin":
+		path = "builtin"
+	default:
+		path = sf.imports[name]
+	}
+	s, err := whatis(&ctx.Build, eval.Cwd, path, sym)
+	if err != nil {
+		return err
+	}
+	return e.nvim.WriteOut(s + "\n")
+}
+
+// onDocReturn displays the documentation for the type returned by the
+// function called at the cursor.
+func (e *explorer) onDocReturn(eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Name  string `eval:"expand('%:p')"`
+	Bufnr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
+	Panel panelOptions
+}) error {
+	sf, err := parseSource(nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)))
+	if err != nil {
+		return err
+	}
+	name, sym, err := sf.callTarget(eval.Line, eval.Col)
+	if err != nil {
+		return err
+	}
+	ctx := context.Get(&eval.Env)
+	var path string
+	if name == "" {
+		path = resolvePackageSpec(&ctx.Build, eval.Cwd, "", nil, eval.Name)
+	} else {
+		path = sf.imports[name]
+	}
+	path, typ, err := returnType(&ctx.Build, eval.Cwd, path, sym)
+	if err != nil {
+		return err
+	}
+	curName, err := e.usePanel(&eval.Panel, "")
+	if err != nil {
+		return err
+	}
+	return e.openDoc(curName, docName(path, ""), typ)
+}
+
+// onDocGrep loads the lines in the package of the current documentation
+// page containing a string into the quickfix list.
+func (e *explorer) onDocGrep(args []string, eval *struct {
+	Env  context.Env
+	Cwd  string `eval:"getcwd()"`
+	Name string `eval:"expand('%')"`
+}) error {
+	if !strings.HasPrefix(eval.Name, bufNamePrefix) {
+		return errors.New("not a documentation buffer")
+	}
+	ctx := context.Get(&eval.Env)
+	bctx, importPath := parseDocName(&ctx.Build, eval.Name)
+	if importPath == "" {
+		return errors.New("no package for the root page")
+	}
+	qfl, err := grepPackage(bctx, ctx.Environ, importPath, eval.Cwd, args[0])
+	if err != nil {
+		return err
+	}
+	if len(qfl) == 0 {
+		return e.nvim.WriteOut(fmt.Sprintf("%s not found in %s\n", args[0], importPath))
+	}
+	return quickfix.Set(e.nvim, qfl)
+}
+
+// onDocSym opens the documentation for the first package that exports the
+// symbol and remembers the packages for :Godocsymnext and :Godocsymprev.
+func (e *explorer) onDocSym(args []string, eval *struct {
+	Env  context.Env
+	Name string `eval:"expand('%')"`
+}) error {
+	name := args[0]
+	if !ast.IsExported(name) {
+		return fmt.Errorf("%s is not exported", name)
+	}
+	ctx := context.Get(&eval.Env)
+	paths := exportingPackages(&ctx.Build, name)
+	if len(paths) == 0 {
+		return fmt.Errorf("no package exports %s", name)
+	}
+	e.mu.Lock()
+	e.symName, e.symPaths, e.symIndex = name, paths, 0
+	e.mu.Unlock()
+	return e.showDocSym(eval.Name, 0)
+}
+
+func (e *explorer) onDocSymNext(eval *struct {
+	Name string `eval:"expand('%')"`
+}) error {
+	return e.showDocSym(eval.Name, 1)
+}
+
+func (e *explorer) onDocSymPrev(eval *struct {
+	Name string `eval:"expand('%')"`
+}) error {
+	return e.showDocSym(eval.Name, -1)
+}
+
+// showDocSym adds step to the index of the current package exporting the
+// symbol from :Godocsym, wrapping around at the ends of the list, and opens
+// the documentation for the package.
+func (e *explorer) showDocSym(curName string, step int) error {
+	e.mu.Lock()
+	if len(e.symPaths) == 0 {
+		e.mu.Unlock()
+		return errors.New("no symbol search, use :Godocsym")
+	}
+	n := len(e.symPaths)
+	e.symIndex = ((e.symIndex+step)%n + n) % n
+	name, importPath, i := e.symName, e.symPaths[e.symIndex], e.symIndex
+	e.mu.Unlock()
+	if err := e.openDoc(curName, docName(importPath, ""), name); err != nil {
+		return err
+	}
+	return e.nvim.WriteOut(fmt.Sprintf("%s.%s (%d of %d)\n", importPath, name, i+1, n))
+}
+
+// onDocExamples toggles the examples on the documentation page in the
+// current buffer.
+func (e *explorer) onDocExamples(eval *struct {
+	Name string `eval:"expand('%')"`
+}) error {
+	if !strings.HasPrefix(eval.Name, bufNamePrefix) {
+		return errors.New("not a documentation buffer")
+	}
+	return e.nvim.Command("let b:vigor_hide_examples = !get(b:, 'vigor_hide_examples', get(g:, 'vigor_hide_examples', 0)) | " + doc.ReloadCmd)
+}
+
+// onDocUse opens the source file of the package on the current page at the
+// first use of the package linked at the cursor.
+func (e *explorer) onDocUse(eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Name  string `eval:"expand('%')"`
+	Bufnr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
+}) error {
+	if !strings.HasPrefix(eval.Name, bufNamePrefix) {
+		return errors.New("not a documentation buffer")
+	}
+	t, ok := e.docm.Link(eval.Bufnr, eval.Line, eval.Col)
+	if !ok {
+		return errors.New("no link under cursor")
+	}
+	if !strings.HasPrefix(t.Path, bufNamePrefix) || t.Path == eval.Name {
+		return errors.New("link is not to another package")
+	}
+	ctx := context.Get(&eval.Env)
+	_, usePath := parseDocName(&ctx.Build, t.Path)
+	bctx, importPath := parseDocName(&ctx.Build, eval.Name)
+	pkg, err := loadPackage(bctx, importPath, eval.Cwd, 0)
+	if err != nil {
+		return err
+	}
+	pos, ok := pkg.ImportUses[usePath]
+	if !ok {
+		return fmt.Errorf("%s is not imported by %s", usePath, pkg.Build.ImportPath)
+	}
+	return e.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)",
+		filepath.Join(pkg.Build.Dir, pos.Filename), pos.Line, pos.Column))
+}
+
 func (e *explorer) onDef(args []string, eval *struct {
 	Env   context.Env
 	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Name  string `eval:"expand('%')"`
 	Bufnr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+	Col   int    `eval:"col('.')"`
 }) error {
-	if len(args) < 1 || len(args) > 2 {
-		return errors.New("one or two arguments required")
+	if len(args) > 2 {
+		return errors.New("zero to two arguments required")
+	}
+
+	ctx := context.Get(&eval.Env)
+
+	if len(args) == 0 && strings.HasPrefix(eval.Name, bufNamePrefix) {
+		// Jump to the definition of the link under the cursor.
+		t, ok := e.docm.Link(eval.Bufnr, eval.Line, eval.Col)
+		if !ok {
+			return errors.New("no link under cursor")
+		}
+		if t.Line > 0 {
+			return e.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)", t.Path, t.Line, t.Column))
+		}
+		if t.Path == "" {
+			t.Path = eval.Name
+		}
+		if !strings.HasPrefix(t.Path, bufNamePrefix) {
+			return errors.New("link is not to a Go declaration")
+		}
+		bctx, path := parseDocName(&ctx.Build, t.Path)
+		file, line, col, err := findDef(bctx, eval.Cwd, path, t.Anchor)
+		if err != nil {
+			return defError(err)
+		}
+		return e.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)", file, line, col))
+	}
+
+	if len(args) == 0 {
+		// Jump to the definition of the identifier under the cursor.
+		sf, err := parseSource(nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)))
+		if err != nil {
+			return err
+		}
+		if line, col, ok := sf.localDef(eval.Line, eval.Col); ok {
+			return e.nvim.Command(fmt.Sprintf(`execute "normal! m'" | call cursor(%d, %d)`, line, col))
+		}
+		name, sym, err := sf.identTarget(eval.Line, eval.Col)
+		if err != nil {
+			return err
+		}
+		file, line, col, err := identDef(&ctx.Build, eval.Cwd, eval.Dir, sf, name, sym)
+		if err != nil {
+			return defError(err)
+		}
+		return e.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)", file, line, col))
 	}
 
 	spec, err := e.expandSpec(args[0])
@@ -87,61 +634,529 @@ func (e *explorer) onDef(args []string, eval *struct {
 		return err
 	}
 
-	ctx := context.Get(&eval.Env)
-	path := resolvePackageSpec(&ctx.Build, eval.Cwd, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+	path := resolvePackageSpec(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
 
 	var sym string
 	if len(args) >= 2 {
-		sym = strings.Trim(args[1], ".")
+		sym, err = e.expandSpec(args[1])
+		if err != nil {
+			return err
+		}
+		sym = strings.Trim(sym, ".")
 	}
 
 	file, line, col, err := findDef(&ctx.Build, eval.Cwd, path, sym)
 	if err != nil {
-		return errors.New("definition not found")
+		return defError(err)
 	}
 
 	return e.nvim.Command(fmt.Sprintf("edit %s | call cursor(%d, %d)", file, line, col))
 }
 
-func (e *explorer) onComplete(a *nvim.CommandCompletionArgs, eval *struct {
+// onRef loads the references to an exported top-level name in a package
+// into the quickfix list. The files in the module containing the current
+// directory are searched. With a bang or outside of a module, the files in
+// the source directories of the build context are searched.
+func (e *explorer) onRef(args []string, bang bool, eval *struct {
 	Env   context.Env
 	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
 	Bufnr int    `eval:"bufnr('%')"`
+}) error {
+	if len(args) != 2 {
+		return errors.New("package and symbol required")
+	}
+
+	ctx := context.Get(&eval.Env)
+
+	spec, err := e.expandSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	sym, err := e.expandSpec(args[1])
+	if err != nil {
+		return err
+	}
+	sym = strings.Trim(sym, ".")
+	if !ast.IsExported(sym) || strings.Contains(sym, ".") {
+		return fmt.Errorf("%s is not an exported top-level name", sym)
+	}
+
+	bpkg, err := refPackage(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+	if err != nil {
+		return err
+	}
+	if !declaresName(&ctx.Build, bpkg.Dir, sym) {
+		return fmt.Errorf("%s not found in %s", sym, bpkg.ImportPath)
+	}
+
+	dirs := ctx.Build.SrcDirs()
+	module := false
+	if fname := findModFile(eval.Cwd); fname != "" && !bang {
+		dirs = []string{filepath.Dir(fname)}
+		module = true
+	}
+	progress := func(n int) {
+		e.nvim.Command(fmt.Sprintf("redraw | echo 'Searched %d directories'", n))
+	}
+	qfl := findRefs(&ctx.Build, dirs, module, bpkg.ImportPath, bpkg.Name, sym, progress)
+	if len(qfl) == 0 {
+		return e.nvim.WriteOut(fmt.Sprintf("no references to %s.%s found\n", bpkg.ImportPath, sym))
+	}
+	return quickfix.Set(e.nvim, qfl)
+}
+
+// onImpl loads the types implementing an interface into the quickfix list.
+// The packages in the module containing the current directory are searched.
+// Outside of a module, the packages in the current directory and its
+// subdirectories are searched.
+func (e *explorer) onImpl(args []string, eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Bufnr int    `eval:"bufnr('%')"`
+}) error {
+	if len(args) != 2 {
+		return errors.New("package and interface required")
+	}
+
+	ctx := context.Get(&eval.Env)
+
+	spec, err := e.expandSpec(args[0])
+	if err != nil {
+		return err
+	}
+	path := resolvePackageSpec(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+
+	name, err := e.expandSpec(args[1])
+	if err != nil {
+		return err
+	}
+	name = strings.Trim(name, ".")
+
+	dir := eval.Cwd
+	module := false
+	importPathFn := func(d string) string { return relativeImportPath(eval.Cwd, d) }
+	if fname := findModFile(eval.Cwd); fname != "" {
+		dir = filepath.Dir(fname)
+		module = true
+		if moduleImportPath(dir) != "" {
+			importPathFn = moduleImportPath
+		}
+	}
+	progress := func(n int) {
+		e.nvim.Command(fmt.Sprintf("redraw | echo 'Searched %d directories'", n))
+	}
+	qfl, err := findImpls(&ctx.Build, eval.Cwd, path, name, dir, module, importPathFn, progress)
+	if err != nil {
+		return err
+	}
+	if len(qfl) == 0 {
+		return e.nvim.WriteOut(fmt.Sprintf("no implementations of %s.%s found\n", path, name))
+	}
+	return quickfix.Set(e.nvim, qfl)
+}
+
+func (e *explorer) onDocSnippet(r [2]int, eval *struct {
+	Env     context.Env
+	Options docOptions
+	Bufnr   int `eval:"bufnr('%')"`
+}) error {
+	lines, err := e.nvim.BufferLines(nvim.Buffer(eval.Bufnr), r[0]-1, r[1], true)
+	if err != nil {
+		return err
+	}
+	ctx := context.Get(&eval.Env)
+	d, err := printSnippet(&ctx.Build, bytes.Join(lines, []byte{'\n'}), &eval.Options)
+	if err != nil {
+		return err
+	}
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	return e.docm.Display(d, buf)
+}
+
+func (e *explorer) onDocBuffers() error {
+	bufs := e.docm.Buffers()
+	names := make([]string, len(bufs))
+	b := e.nvim.NewBatch()
+	for i, buf := range bufs {
+		b.BufferName(nvim.Buffer(buf), &names[i])
+	}
+	if err := b.Execute(); err != nil {
+		return err
+	}
+	d := printBuffers(names)
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	return e.docm.Display(d, buf)
+}
+
+// onDocBin lists the modules that a binary was built from in a new window.
+func (e *explorer) onDocBin(args []string, eval *struct {
+	Env context.Env
+	Cwd string `eval:"getcwd()"`
+}) error {
+	fname, err := e.expandSpec(args[0])
+	if err != nil {
+		return err
+	}
+	info, err := buildinfo.ReadFile(fname)
+	if err != nil {
+		return fmt.Errorf("cannot read build info: %v", err)
+	}
+	ctx := context.Get(&eval.Env)
+	d := printBuildInfo(info, fname, filepath.SplitList(ctx.Build.GOPATH), eval.Cwd)
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	return e.docm.Display(d, buf)
+}
+
+// symbolIndexJSON returns the symbol index for the package specification as
+// JSON.
+func (e *explorer) symbolIndexJSON(spec string, env *context.Env, cwd, dir string, bufnr int) ([]byte, error) {
+	spec, err := e.expandSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Get(env)
+	path := resolvePackageSpec(&ctx.Build, cwd, dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(bufnr)), spec)
+	syms, err := symbolIndex(&ctx.Build, cwd, path)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(syms, "", "  ")
+}
+
+// onDocOverview displays the package doc comment in a small window.
+func (e *explorer) onDocOverview(args []string, eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Bufnr int    `eval:"bufnr('%')"`
+}) error {
+	spec, err := e.expandSpec(args[0])
+	if err != nil {
+		return err
+	}
+	ctx := context.Get(&eval.Env)
+	path := resolvePackageSpec(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+	d, err := printPackageOverview(&ctx.Build, path, eval.Cwd)
+	if err != nil {
+		return err
+	}
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	if err := e.docm.Display(d, buf); err != nil {
+		return err
+	}
+	return e.nvim.Command(fmt.Sprintf("execute 'resize' min([line('$'), %d])", overviewHeight))
+}
+
+// overviewHeight is the maximum height of the :Godocoverview window.
+const overviewHeight = 15
+
+func (e *explorer) onSymbols(args []string, eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Bufnr int    `eval:"bufnr('%')"`
+}) error {
+	p, err := e.symbolIndexJSON(args[0], &eval.Env, eval.Cwd, eval.Dir, eval.Bufnr)
+	if err != nil {
+		return err
+	}
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	b := e.nvim.NewBatch()
+	b.SetBufferLines(buf, 0, -1, true, bytes.Split(p, []byte{'\n'}))
+	b.SetBufferOption(buf, "buftype", "nofile")
+	b.SetBufferOption(buf, "filetype", "json")
+	b.SetBufferOption(buf, "modified", false)
+	return b.Execute()
+}
+
+// onReset clears the cached state of the plugin.
+func (e *explorer) onReset() error {
+	context.Clear()
+	clearPackageCache()
+	clearMethodSetCache()
+	e.docm.Clear()
+	e.mu.Lock()
+	e.symName, e.symPaths, e.symIndex = "", nil, 0
+	e.mu.Unlock()
+	return e.nvim.WriteOut("vigor: state cleared\n")
+}
+
+func (e *explorer) onConfig(eval *struct {
+	Env        context.Env
+	Options    docOptions
+	Completion completionOptions
+	UpKey      string `eval:"get(g:, 'vigor_up_key', '-')"`
+}) error {
+	p := configText(context.Get(&eval.Env), &eval.Options, &eval.Completion, eval.UpKey, len(e.docm.Buffers()))
+	if err := e.nvim.Command("new"); err != nil {
+		return err
+	}
+	buf, err := e.nvim.CurrentBuffer()
+	if err != nil {
+		return err
+	}
+	b := e.nvim.NewBatch()
+	b.SetBufferLines(buf, 0, -1, true, bytes.Split(bytes.TrimSuffix(p, []byte{'\n'}), []byte{'\n'}))
+	b.SetBufferOption(buf, "buftype", "nofile")
+	b.SetBufferOption(buf, "modified", false)
+	return b.Execute()
+}
+
+func (e *explorer) onSymbolIndex(args []string, eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Bufnr int    `eval:"bufnr('%')"`
+}) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("one argument required")
+	}
+	p, err := e.symbolIndexJSON(args[0], &eval.Env, eval.Cwd, eval.Dir, eval.Bufnr)
+	return string(p), err
+}
+
+// labelOptions specifies how documentation buffers are labeled in
+// statuslines.
+type labelOptions struct {
+	// Prefix is prepended to the import path in the label.
+	Prefix string `eval:"get(g:, 'vigor_label_prefix', '[godoc] ')"`
+
+	// Statusline specifies that documentation buffers set a local
+	// statusline showing the label.
+	Statusline bool `eval:"get(g:, 'vigor_statusline_label', 0)"`
+}
+
+// labelStatusline is the local statusline set on documentation buffers when
+// g:vigor_statusline_label is set.
+const labelStatusline = `setlocal statusline=%<%{get(b:,'vigor_label','')}\ %h%m%r%=%-14.(%l,%c%V%)\ %P`
+
+func (e *explorer) onBufferLabel(args []string, eval *struct {
+	Label labelOptions
+	Name  string `eval:"bufname('%')"`
+}) (string, error) {
+	name := eval.Name
+	if len(args) > 0 {
+		bufnr, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", fmt.Errorf("invalid buffer number %q", args[0])
+		}
+		if err := e.nvim.Call("bufname", &name, bufnr); err != nil {
+			return "", err
+		}
+	}
+	return bufferLabel(name, eval.Label.Prefix), nil
+}
+
+func (e *explorer) onSigDiff(args []string, eval *struct {
+	Env   context.Env
+	Cwd   string `eval:"getcwd()"`
+	Dir   string `eval:"expand('%:p:h')"`
+	Bufnr int    `eval:"bufnr('%')"`
+}) error {
+	if len(args) != 3 {
+		return errors.New("three arguments required")
+	}
+
+	ctx := context.Get(&eval.Env)
+	sym := strings.Trim(args[2], ".")
+
+	var docs []*doc.Doc
+	for _, arg := range args[:2] {
+		spec, err := e.expandSpec(arg)
+		if err != nil {
+			return err
+		}
+		path := resolvePackageSpec(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+		d, err := printSignature(&ctx.Build, eval.Cwd, path, sym)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, d)
+	}
+
+	// Show the signatures side by side in diff mode.
+	for i, d := range docs {
+		cmd := "tabnew"
+		if i > 0 {
+			cmd = "vnew"
+		}
+		if err := e.nvim.Command(cmd); err != nil {
+			return err
+		}
+		buf, err := e.nvim.CurrentBuffer()
+		if err != nil {
+			return err
+		}
+		if err := e.docm.Display(d, buf); err != nil {
+			return err
+		}
+		if err := e.nvim.Command("diffthis"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *explorer) onComplete(a *nvim.CommandCompletionArgs, eval *struct {
+	Env        context.Env
+	Cwd        string `eval:"getcwd()"`
+	Dir        string `eval:"expand('%:p:h')"`
+	Bufnr      int    `eval:"bufnr('%')"`
+	Completion completionOptions
 }) ([]string, error) {
 
 	ctx := context.Get(&eval.Env)
 
-	f := strings.Fields(a.CmdLine)
+	if strings.HasPrefix(a.ArgLead, "-") {
+		var completions []string
+		for _, flag := range docFlags {
+			if strings.HasPrefix(flag, a.ArgLead) {
+				completions = append(completions, flag)
+			}
+		}
+		return completions, nil
+	}
+
+	// Options and option values do not count as arguments.
+	var f []string
+	fields := strings.Fields(a.CmdLine)
+	for i := 0; i < len(fields); i++ {
+		switch s := fields[i]; {
+		case s == "-tags":
+			i++
+		case !strings.HasPrefix(s, "-"):
+			f = append(f, s)
+		}
+	}
+
+	// Gosigdiff takes two package arguments before the symbol.
+	npkg := 1
+	if len(f) > 0 && f[0] == "Gosigdiff" {
+		npkg = 2
+	}
+
+	// The argument following a type is a method or field of the type.
+	isMember := len(f) > 0 && f[0] == "Godoc" && (len(f) >= npkg+3 || (len(f) == npkg+2 && a.ArgLead == ""))
+
 	var completions []string
-	if len(f) >= 3 || (len(f) == 2 && a.ArgLead == "") {
+	if isMember || len(f) >= npkg+2 || (len(f) == npkg+1 && a.ArgLead == "") {
 		spec, err := e.expandSpec(f[1])
 		if err != nil {
-			return nil, err
+			// Do not report expansion errors while completing.
+			return nil, nil
+		}
+		path := resolvePackageSpec(&ctx.Build, eval.Cwd, eval.Dir, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec)
+		if isMember {
+			completions = completeMemberArg(&ctx.Build, path, f[npkg+1], a.ArgLead, eval.Completion.Fuzzy)
+		} else {
+			completions = completeSymMethodArg(&ctx.Build, path, a.ArgLead, eval.Completion.Fuzzy)
 		}
-		completions = completeSymMethodArg(&ctx.Build, resolvePackageSpec(&ctx.Build, eval.Cwd, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), spec), a.ArgLead)
 	} else {
-		completions = completePackageArg(&ctx.Build, eval.Cwd, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), a.ArgLead)
+		completions = completePackageArg(&ctx.Build, eval.Cwd, nvim.NewBufferReader(e.nvim, nvim.Buffer(eval.Bufnr)), a.ArgLead, eval.Completion.Scope)
 	}
 	return completions, nil
 }
 
+// toggleMapping returns a buffer-local mapping for lhs that toggles the
+// buffer variable b:name and renders the page again. The value of g:name is
+// the initial value of the toggle.
+func toggleMapping(lhs string, name string) string {
+	return fmt.Sprintf("nnoremap <buffer> <silent> %s :<C-U>let b:%s = !get(b:, '%s', get(g:, '%s', 0)) <Bar> %s<CR>",
+		lhs, name, name, name, strings.Replace(doc.ReloadCmd, "|", "<Bar>", -1))
+}
+
+// detailMapping returns a buffer-local mapping for lhs that adds step to the
+// page detail level and renders the page again.
+func detailMapping(lhs string, step int) string {
+	return fmt.Sprintf("nnoremap <buffer> <silent> %s :<C-U>let b:vigor_detail = max([%d, min([%d, get(b:, 'vigor_detail', get(g:, 'vigor_detail', %d)) + %d])]) <Bar> %s<CR>",
+		lhs, detailSignatures, detailSource, detailExamples, step, strings.Replace(doc.ReloadCmd, "|", "<Bar>", -1))
+}
+
+// sectionMapping returns a buffer-local mapping for lhs that moves the cursor
+// to the start of the named section recorded in b:vigor_sections. The cursor
+// does not move if the page does not have the section.
+func sectionMapping(lhs string, name string) string {
+	return fmt.Sprintf("nnoremap <buffer> <silent> %s :<C-U>if has_key(get(b:, 'vigor_sections', {}), '%s') <Bar> call cursor(b:vigor_sections['%s'], 1) <Bar> endif<CR>",
+		lhs, name, name)
+}
+
+// pageMappings are the buffer-local mappings for documentation pages.
+var pageMappings = []string{
+	toggleMapping("gp", "vigor_full_import_paths"),
+	detailMapping("+", 1),
+	detailMapping("_", -1),
+	"if get(g:, 'vigor_up_key', '-') !=# '-' | " + detailMapping("-", -1) + "| endif",
+	`nnoremap <buffer> <silent> gD :<C-U>Godef<CR>`,
+	`nnoremap <buffer> g/ :<C-U>Godocgrep<Space>`,
+	`nnoremap <buffer> <silent> gu :<C-U>Godocuse<CR>`,
+	`nnoremap <buffer> <silent> ge :<C-U>Godocexamples<CR>`,
+	sectionMapping("go", packageSection),
+	sectionMapping("gl", directoriesSection),
+	`nnoremap <buffer> <silent> ]p :<C-U>Godocsymnext<CR>`,
+	`nnoremap <buffer> <silent> [p :<C-U>Godocsymprev<CR>`,
+	`nnoremap <buffer> <silent> gy :<C-U>if exists('b:vigor_import') <Bar> call setreg(v:register, b:vigor_import) <Bar> echo b:vigor_import <Bar> endif<CR>`,
+}
+
 func (e *explorer) onBufReadCmd(eval *struct {
-	Env   context.Env
-	Cwd   string `eval:"getcwd()"`
-	Name  string `eval:"expand('%')"`
-	Bufnr int    `eval:"bufnr('%')"`
+	Env     context.Env
+	Options docOptions
+	Cwd     string `eval:"getcwd()"`
+	Name    string `eval:"expand('%')"`
+	Bufnr   int    `eval:"bufnr('%')"`
+	Label   labelOptions
 }) error {
 
 	ctx := context.Get(&eval.Env)
-	d, err := printDoc(&ctx.Build, eval.Name, eval.Cwd)
+	d, err := printDoc(&ctx.Build, eval.Name, eval.Cwd, &eval.Options)
 	if err != nil {
-		d := doc.NewDoc()
-		d.WriteString(err.Error())
+		d = printError(err)
+	}
+	d.SetVar("vigor_label", bufferLabel(eval.Name, eval.Label.Prefix))
+	if err := e.docm.Display(d, nvim.Buffer(eval.Bufnr)); err != nil {
+		return err
+	}
+	b := e.nvim.NewBatch()
+	for _, m := range pageMappings {
+		b.Command(m)
+	}
+	if eval.Label.Statusline {
+		b.Command(labelStatusline)
 	}
-	return e.docm.Display(d, nvim.Buffer(eval.Bufnr))
+	return b.Execute()
 	/*
 		p.Command("nnoremap <buffer> <silent> g? :<C-U>help :Godoc<CR>")
-		p.Command(`nnoremap <buffer> <silent> ]] :<C-U>call search('\C\v^[^ \t)}]', 'W')<CR>`)
-		p.Command(`nnoremap <buffer> <silent> [[ :<C-U>call search('\C\v^[^ \t)}]', 'Wb')<CR>`)
 	*/
 }
@@ -0,0 +1,64 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build"
+	"os/exec"
+
+	"github.com/garyburd/vigor/src/quickfix"
+	"github.com/neovim/go-client/nvim"
+)
+
+// grepCommand returns the command for searching the files in dir for the
+// fixed string s. The command uses rg if it is installed and grep otherwise.
+func grepCommand(s string, dir string, files []string) *exec.Cmd {
+	var c *exec.Cmd
+	if _, err := exec.LookPath("rg"); err == nil {
+		c = exec.Command("rg", append([]string{"--vimgrep", "--fixed-strings", "--regexp", s, "--"}, files...)...)
+	} else {
+		c = exec.Command("grep", append([]string{"--line-number", "--with-filename", "--fixed-strings", "--regexp", s, "--"}, files...)...)
+	}
+	c.Dir = dir
+	return c
+}
+
+// grepPackage searches the source files of the package named by importPath
+// for the fixed string s. The matches are returned as quickfix errors.
+func grepPackage(ctx *build.Context, environ []string, importPath, cwd, s string) ([]*nvim.QuickfixError, error) {
+	bpkg, err := importPackage(ctx, importPath, cwd)
+	if _, ok := err.(*build.MultiplePackageError); ok {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range [][]string{bpkg.GoFiles, bpkg.CgoFiles, bpkg.IgnoredGoFiles, bpkg.TestGoFiles, bpkg.XTestGoFiles} {
+		files = append(files, f...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", bpkg.Dir)
+	}
+	var stdout, stderr bytes.Buffer
+	c := grepCommand(s, bpkg.Dir, files)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	c.Env = environ
+	if err := c.Run(); err != nil {
+		if e, ok := err.(*exec.ExitError); ok && e.ExitCode() == 1 {
+			// Exit status 1 is no matches.
+			return nil, nil
+		}
+		if stderr.Len() > 0 {
+			return nil, errors.New(string(bytes.TrimSpace(stderr.Bytes())))
+		}
+		return nil, err
+	}
+	return quickfix.Parse(stdout.Bytes(), bpkg.Dir, 0), nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+var grepPackageTests = []struct {
+	s     string
+	lines []int
+}{
+	{"promoted to Outer", []int{7, 10}},
+	{"Outer)", []int{19}},
+	{"(*Inner", []int{11}},
+	{"missing", nil},
+}
+
+func TestGrepPackage(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		if _, err := exec.LookPath("grep"); err != nil {
+			t.Skip("rg and grep not found")
+		}
+	}
+	ctx := context.Get(&context.Env{})
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fname := filepath.Join(cwd, "testdata", "embed", "embed.go")
+	for _, tt := range grepPackageTests {
+		qfl, err := grepPackage(&ctx.Build, ctx.Environ, "./testdata/embed", cwd, tt.s)
+		if err != nil {
+			t.Errorf("grepPackage(%q) returned error %v", tt.s, err)
+			continue
+		}
+		var lines []int
+		for _, qfe := range qfl {
+			if qfe.FileName != fname {
+				t.Errorf("grepPackage(%q) file = %q, want %q", tt.s, qfe.FileName, fname)
+			}
+			lines = append(lines, qfe.LNum)
+		}
+		if !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("grepPackage(%q) lines = %v, want %v", tt.s, lines, tt.lines)
+		}
+	}
+}
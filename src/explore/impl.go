@@ -0,0 +1,175 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+// implLoadFlags are the flags for loading the packages searched for
+// implementations of an interface.
+const implLoadFlags = loadPackageDoc | loadPackageUnexported | loadPackageAllMethods
+
+// typeMethodSet is the set of method names of a type declared in a package.
+type typeMethodSet struct {
+	name    string
+	iface   bool
+	methods map[string]bool
+
+	// The position of the type name in the declaration.
+	file      string
+	line, col int
+}
+
+// cachedMethodSets is an entry in the method set cache.
+type cachedMethodSets struct {
+	types    []*typeMethodSet
+	modTimes map[string]time.Time
+}
+
+// methodSetCache maps packages to the method sets of the types declared in
+// the packages. Method sets are cached separately from packages so that a
+// search of all packages in a module does not evict the packages in the
+// package cache.
+var methodSetCache = struct {
+	mu      sync.Mutex
+	entries map[loadKey]*cachedMethodSets
+}{
+	entries: make(map[loadKey]*cachedMethodSets),
+}
+
+// clearMethodSetCache removes all method sets from the cache.
+func clearMethodSetCache() {
+	methodSetCache.mu.Lock()
+	methodSetCache.entries = make(map[loadKey]*cachedMethodSets)
+	methodSetCache.mu.Unlock()
+}
+
+// packageMethodSets returns the method sets of the types declared in the
+// package importPath. The methods of a type include the methods promoted
+// from embedded types and the methods with pointer receivers. The method
+// sets are cached until a file in the package changes.
+func packageMethodSets(ctx *build.Context, importPath, srcDir string) ([]*typeMethodSet, error) {
+	key := newLoadKey(ctx, importPath, srcDir, implLoadFlags)
+	methodSetCache.mu.Lock()
+	c := methodSetCache.entries[key]
+	methodSetCache.mu.Unlock()
+	if c != nil && !modTimesChanged(c.modTimes) {
+		return c.types, nil
+	}
+
+	pkg, err := loadPackage(ctx, importPath, srcDir, implLoadFlags)
+	if err != nil {
+		return nil, err
+	}
+	var types []*typeMethodSet
+	if pkg.GoDoc != nil {
+		for _, t := range pkg.GoDoc.Types {
+			ts := typeSpec(t.Decl, t.Name)
+			if ts == nil {
+				continue
+			}
+			_, iface := ts.Type.(*ast.InterfaceType)
+			p := pkg.FSet.Position(ts.Name.Pos())
+			ms := &typeMethodSet{
+				name:    t.Name,
+				iface:   iface,
+				methods: make(map[string]bool),
+				file:    filepath.Join(pkg.Build.Dir, p.Filename),
+				line:    p.Line,
+				col:     p.Column,
+			}
+			for _, name := range typeMethods(ctx, pkg, t) {
+				ms.methods[name] = true
+			}
+			types = append(types, ms)
+		}
+	}
+
+	methodSetCache.mu.Lock()
+	methodSetCache.entries[key] = &cachedMethodSets{types: types, modTimes: packageModTimes(pkg.Build, srcDir)}
+	methodSetCache.mu.Unlock()
+	return types, nil
+}
+
+// typeSpec returns the spec for the type name in decl or nil if the spec
+// is not found.
+func typeSpec(decl *ast.GenDecl, name string) *ast.TypeSpec {
+	for _, spec := range decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+			return ts
+		}
+	}
+	return nil
+}
+
+// implements returns true if the method set of t includes the methods of
+// the interface iface.
+func (t *typeMethodSet) implements(iface *typeMethodSet) bool {
+	for name := range iface.methods {
+		if !t.methods[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// findImpls returns the declarations of the non-interface types that
+// implement the interface name in the package importPath as quickfix
+// errors. The packages in dir and its subdirectories are searched as
+// described in walkSourceDirs. The packages are loaded by directory. The
+// function importPathFn returns the import path of a package directory for
+// the text of the quickfix errors. Methods are matched by name only.
+func findImpls(ctx *build.Context, cwd, importPath, name, dir string, module bool, importPathFn func(dir string) string, progress func(int)) ([]*nvim.QuickfixError, error) {
+	types, err := packageMethodSets(ctx, importPath, cwd)
+	if err != nil {
+		return nil, err
+	}
+	var iface *typeMethodSet
+	for _, t := range types {
+		if t.name == name {
+			iface = t
+		}
+	}
+	if iface == nil {
+		return nil, newError(errorSymbolNotFound, "%s not found in %s", name, importPath)
+	}
+	if !iface.iface {
+		return nil, fmt.Errorf("%s.%s is not an interface", importPath, name)
+	}
+
+	var qfl []*nvim.QuickfixError
+	ndirs := 0
+	walkSourceDirs(ctx, dir, module, func(dir string, fis []os.FileInfo) {
+		ndirs++
+		if progress != nil && ndirs%progressDirs == 0 {
+			progress(ndirs)
+		}
+		types, err := packageMethodSets(ctx, relativeImportPath(cwd, dir), cwd)
+		if err != nil {
+			return
+		}
+		p := importPathFn(dir)
+		for _, t := range types {
+			if !t.iface && t.implements(iface) {
+				qfl = append(qfl, &nvim.QuickfixError{
+					FileName: t.file,
+					LNum:     t.line,
+					Col:      t.col,
+					Text:     p + "." + t.name,
+				})
+			}
+		}
+	})
+	return qfl, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestFindImpls(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	dir, _ := filepath.Abs(filepath.Join("testdata", "impl"))
+	importPathFn := func(d string) string { return relativeImportPath(dir, d) }
+	for _, tt := range []struct {
+		name string
+		want []string
+	}{
+		{"Shape", []string{"./circle.Circle", "./circle.Half", "./shape.Square"}},
+		{"Namer", nil},
+	} {
+		qfl, err := findImpls(&ctx.Build, dir, "./shape", tt.name, dir, false, importPathFn, nil)
+		if err != nil {
+			t.Errorf("findImpls(%s) returned error %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, qfe := range qfl {
+			got = append(got, qfe.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findImpls(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if _, err := findImpls(&ctx.Build, dir, "./shape", "Square", dir, false, importPathFn, nil); err == nil {
+		t.Errorf("findImpls(Square) did not return an error")
+	}
+
+	modDir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/m\n",
+		"a/a.go": "package a\n\ntype I interface{ M() }\n",
+		"b/b.go": "package b\n\ntype T struct{}\n\nfunc (T) M() {}\n",
+	})
+	defer os.RemoveAll(modDir)
+	qfl, err := findImpls(&ctx.Build, modDir, "./a", "I", modDir, true, moduleImportPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, qfe := range qfl {
+		got = append(got, qfe.Text)
+	}
+	if want := []string{"example.com/m/b.T"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findImpls(I, module=true) = %q, want %q", got, want)
+	}
+}
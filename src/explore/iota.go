@@ -0,0 +1,215 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// iotaComments returns line comments with the computed values of the
+// constants in a const declaration using iota. Specs with a line comment are
+// skipped.
+func (p *docPrinter) iotaComments(decl *ast.GenDecl) []*ast.CommentGroup {
+	if decl.Tok != token.CONST || !usesIota(decl) {
+		return nil
+	}
+	values := p.constValues(decl)
+	if values == nil {
+		return nil
+	}
+	var comments []*ast.CommentGroup
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok || vs.Comment != nil {
+			continue
+		}
+		var s []string
+		for _, n := range vs.Names {
+			if n.Name == "_" {
+				continue
+			}
+			v, ok := values[n.Name]
+			if !ok {
+				s = nil
+				break
+			}
+			s = append(s, v)
+		}
+		if len(s) == 0 {
+			continue
+		}
+		comments = append(comments, &ast.CommentGroup{List: []*ast.Comment{{
+			Slash: vs.End(),
+			Text:  "// " + strings.Join(s, ", "),
+		}}})
+	}
+	return comments
+}
+
+// usesIota returns true if a value in the declaration refers to iota.
+func usesIota(decl *ast.GenDecl) bool {
+	found := false
+	ast.Inspect(decl, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// constValues returns the values of the constants in decl by name. The
+// declaration is parsed again from source because go/doc removes specs with
+// unexported names, changing the value of iota in the following specs. The
+// types of the constants are removed so that the declaration can be
+// evaluated without the rest of the package. Constants with values that
+// depend on declarations outside of the block are omitted.
+func (p *docPrinter) constValues(decl *ast.GenDecl) map[string]string {
+	tfile := p.FSet.File(decl.Pos())
+	if tfile == nil {
+		return nil
+	}
+	src, err := ioutil.ReadFile(filepath.Join(p.Build.Dir, tfile.Name()))
+	if err != nil {
+		return nil
+	}
+	start, end := tfile.Offset(decl.Pos()), tfile.Offset(decl.End())
+	if end > len(src) {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", "package p\n"+string(src[start:end]), 0)
+	if err != nil || len(file.Decls) != 1 {
+		return nil
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if vs, ok := n.(*ast.ValueSpec); ok {
+			vs.Type = nil
+		}
+		return true
+	})
+
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	conf := &types.Config{Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{file}, info)
+
+	values := make(map[string]string)
+	for id, obj := range info.Defs {
+		c, ok := obj.(*types.Const)
+		if !ok || c.Val().Kind() == constant.Unknown {
+			continue
+		}
+		values[id.Name] = c.Val().ExactString()
+	}
+	return values
+}
+
+// varComments returns line comments with the values of the variables in a
+// var declaration initialized with constant expressions. Specs with a line
+// comment and variables initialized with a literal are skipped.
+func (p *docPrinter) varComments(decl *ast.GenDecl) []*ast.CommentGroup {
+	if decl.Tok != token.VAR || !p.options.VarValues {
+		return nil
+	}
+	if p.varValues == nil {
+		p.varValues = p.packageVarValues()
+	}
+	var comments []*ast.CommentGroup
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok || vs.Comment != nil || len(vs.Values) != len(vs.Names) {
+			continue
+		}
+		var s []string
+		for i, n := range vs.Names {
+			if _, ok := vs.Values[i].(*ast.BasicLit); ok {
+				continue
+			}
+			if v, ok := p.varValues[n.Name]; ok {
+				s = append(s, v)
+			}
+		}
+		if len(s) == 0 {
+			continue
+		}
+		comments = append(comments, &ast.CommentGroup{List: []*ast.Comment{{
+			Slash: vs.End(),
+			Text:  "// = " + strings.Join(s, ", "),
+		}}})
+	}
+	return comments
+}
+
+// packageVarValues returns the values of the package level variables
+// initialized with constant expressions by name. The package files are
+// parsed again and type checked because go/doc removes unexported
+// declarations. Imported packages are not loaded, so expressions using
+// imported constants are omitted.
+func (p *docPrinter) packageVarValues() map[string]string {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range p.Build.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(p.Build.Dir, name), nil, 0)
+		if err != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := &types.Config{
+		Error:    func(error) {},
+		Importer: noImporter{},
+	}
+	conf.Check(p.Build.ImportPath, fset, files, info)
+
+	values := make(map[string]string)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) != len(vs.Names) {
+					continue
+				}
+				for i, n := range vs.Names {
+					if tv, ok := info.Types[vs.Values[i]]; ok && tv.Value != nil && tv.Value.Kind() != constant.Unknown {
+						values[n.Name] = varValueString(tv.Value)
+					}
+				}
+			}
+		}
+	}
+	return values
+}
+
+// varValueString returns the value as it would be written in Go source.
+// Floating point values are shown in decimal notation instead of as
+// fractions.
+func varValueString(v constant.Value) string {
+	switch v.Kind() {
+	case constant.Float, constant.Complex:
+		return v.String()
+	}
+	return v.ExactString()
+}
+
+// noImporter is a types.Importer that does not import packages.
+type noImporter struct{}
+
+func (noImporter) Import(path string) (*types.Package, error) {
+	return nil, errors.New("imports not loaded")
+}
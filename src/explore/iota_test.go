@@ -0,0 +1,63 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestIotaComments(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/iota", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untangleDoc(pkg.GoDoc)
+	if len(pkg.GoDoc.Consts) != 1 {
+		t.Fatalf("got %d const declarations, want 1", len(pkg.GoDoc.Consts))
+	}
+	p := &docPrinter{pkg: pkg}
+	var got []string
+	for _, cg := range p.iotaComments(pkg.GoDoc.Consts[0].Decl) {
+		got = append(got, cg.Text())
+	}
+	want := []string{"0\n", "2\n", "8\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("comments = %q, want %q", got, want)
+	}
+}
+
+func TestVarComments(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/varvalues", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"// = 4096", "// = \"hello, world\"", "// = 0.25"}, nil}
+	if len(pkg.GoDoc.Vars) != len(want) {
+		t.Fatalf("got %d var declarations, want %d", len(pkg.GoDoc.Vars), len(want))
+	}
+	p := &docPrinter{pkg: pkg, options: &docOptions{VarValues: true}}
+	for i, d := range pkg.GoDoc.Vars {
+		var got []string
+		for _, cg := range p.varComments(d.Decl) {
+			got = append(got, cg.List[0].Text)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("%v: comments = %q, want %q", d.Names, got, want[i])
+		}
+	}
+
+	p = &docPrinter{pkg: pkg, options: &docOptions{}}
+	if cgs := p.varComments(pkg.GoDoc.Vars[0].Decl); cgs != nil {
+		t.Errorf("got %d comments with option off, want 0", len(cgs))
+	}
+}
@@ -5,15 +5,23 @@
 package explore
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go/ast"
 	"go/build"
 	godoc "go/doc"
 	"go/parser"
 	"go/token"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
 )
 
 // pkg represents a Go package.
@@ -24,6 +32,19 @@ type pkg struct {
 	GoDoc    *godoc.Package
 	Examples []*godoc.Example
 	Errors   []error
+
+	// ExampleFiles maps examples to the name of the file declaring the
+	// example.
+	ExampleFiles map[*godoc.Example]string
+
+	// Embeds maps the names of variables initialized with //go:embed
+	// directives to the patterns in the directives.
+	Embeds map[string][]string
+
+	// ImportUses maps import paths to the position of the first use of the
+	// import in the package files. The position of the import declaration
+	// is used for imports without a qualified identifier.
+	ImportUses map[string]token.Position
 }
 
 // Flags for loadPackage.
@@ -32,32 +53,121 @@ const (
 	loadPackageExamples
 	loadPackageUnexported
 	loadPackageFixVendor
+	loadPackagePreserveAST
+	loadPackageAllMethods
 )
 
+// loadKey identifies the result of loadPackage. The result depends on the
+// build configuration as well as the package, so a key for a cache of loaded
+// packages must include the configuration. The -mod flag in GOFLAGS selects
+// between vendored packages and the module cache.
+type loadKey struct {
+	importPath string
+	srcDir     string
+	goroot     string
+	gopath     string
+	goos       string
+	goarch     string
+	tags       string
+	cgoEnabled bool
+	goflags    string
+	flags      int
+}
+
+// newLoadKey returns the key for loadPackage(ctx, importPath, srcDir, flags).
+// The order of the build tags does not matter.
+func newLoadKey(ctx *build.Context, importPath string, srcDir string, flags int) loadKey {
+	tags := append([]string(nil), ctx.BuildTags...)
+	sort.Strings(tags)
+	return loadKey{
+		importPath: importPath,
+		srcDir:     srcDir,
+		goroot:     ctx.GOROOT,
+		gopath:     ctx.GOPATH,
+		goos:       ctx.GOOS,
+		goarch:     ctx.GOARCH,
+		tags:       strings.Join(tags, ","),
+		cgoEnabled: ctx.CgoEnabled,
+		goflags:    os.Getenv("GOFLAGS"),
+		flags:      flags,
+	}
+}
+
 // loadPackage returns details about the Go package named by the import
 // path, interpreting local import paths relative to the srcDir directory.
 func loadPackage(ctx *build.Context, importPath string, srcDir string, flags int) (*pkg, error) {
-	bpkg, err := ctx.Import(importPath, srcDir, build.ImportComment)
+	return loadPackageContext(context.Background(), ctx, importPath, srcDir, flags)
+}
+
+// loadPackageContext is like loadPackage, except that the load is abandoned
+// when cctx is done. The context is checked between the parses of the
+// package files. Loaded packages are cached until a file in the package
+// changes. The returned package must not be modified.
+func loadPackageContext(cctx context.Context, ctx *build.Context, importPath string, srcDir string, flags int) (*pkg, error) {
+	key := newLoadKey(ctx, importPath, srcDir, flags)
+	if pkg := getCachedPackage(key); pkg != nil {
+		return pkg, nil
+	}
+	pkg, err := loadPackageFiles(cctx, ctx, importPath, srcDir, flags)
+	if err == nil && pkg.AST != nil {
+		putCachedPackage(key, pkg, srcDir)
+	}
+	return pkg, err
+}
+
+// loadPackageFiles loads the package from the files in the package
+// directory.
+func loadPackageFiles(cctx context.Context, ctx *build.Context, importPath string, srcDir string, flags int) (*pkg, error) {
+	bpkg, err := importPackage(ctx, importPath, srcDir)
+	if cerr := cctx.Err(); cerr != nil {
+		return nil, fmt.Errorf("loading %s: %w", importPath, cerr)
+	}
+	var errs []error
 	if _, ok := err.(*build.NoGoError); ok {
-		return &pkg{Build: bpkg}, nil
+		// Document the files excluded by build constraints, if any.
+		abpkg, aerr := importAllFiles(ctx, bpkg)
+		if aerr != nil {
+			return &pkg{Build: bpkg}, nil
+		}
+		errs = append(errs, newError(errorBuild, "%v; documented from the files excluded by build constraints", err))
+		bpkg, err = abpkg, nil
+	}
+	if e, ok := err.(*build.MultiplePackageError); ok {
+		errs = append(errs, e)
+		bpkg, err = importPrimaryPackage(ctx, bpkg, e)
 	}
 	if err != nil {
-		return nil, err
+		err = importError(bpkg, err)
+		if errorKind(err) != errorBuild {
+			return nil, err
+		}
+		// Make a best effort to document a directory that does not build.
+		abpkg, aerr := importAllFiles(ctx, bpkg)
+		if aerr != nil {
+			return nil, err
+		}
+		errs = append(errs, newError(errorBuild, "%v; documented from all files in the directory", err))
+		bpkg = abpkg
 	}
 
 	pkg := &pkg{
-		FSet:  token.NewFileSet(),
-		Build: bpkg,
+		FSet:   token.NewFileSet(),
+		Build:  bpkg,
+		Errors: errs,
 	}
 
 	files := make(map[string]*ast.File)
 	for _, name := range append(pkg.Build.GoFiles, pkg.Build.CgoFiles...) {
+		if err := cctx.Err(); err != nil {
+			return nil, fmt.Errorf("loading %s: %w", importPath, err)
+		}
 		file, err := pkg.parseFile(ctx, name)
 		if err != nil {
 			pkg.Errors = append(pkg.Errors, err)
-			continue
 		}
-		files[name] = file
+		if file != nil {
+			files[name] = file
+		}
 	}
 
 	vendor := make(map[string]string)
@@ -77,13 +187,28 @@ func loadPackage(ctx *build.Context, importPath string, srcDir string, flags int
 		}
 	}
 
+	// Record the uses before go/doc removes the function bodies.
+	pkg.ImportUses = importUses(pkg.FSet, pkg.AST.Files)
+
 	if flags&loadPackageDoc != 0 {
 		mode := godoc.Mode(0)
 		if pkg.Build.ImportPath == "builtin" || flags&loadPackageUnexported != 0 {
 			mode |= godoc.AllDecls
 		}
+		if flags&loadPackageAllMethods != 0 {
+			// Include methods promoted from embedded types. The
+			// declaration of a promoted method is the declaration in the
+			// embedded type.
+			mode |= godoc.AllMethods
+		}
+		if flags&loadPackagePreserveAST != 0 {
+			mode |= godoc.PreserveAST
+		}
 		pkg.GoDoc = godoc.New(pkg.AST, pkg.Build.ImportPath, mode)
-		if pkg.Build.ImportPath == "builtin" {
+		if pkg.Build.ImportPath == "builtin" || pkg.Build.ImportPath == "unsafe" {
+			// The declarations in the builtin and unsafe packages are
+			// documentation stubs. Functions returning the stub types are
+			// not constructors; list them with the other functions.
 			for _, t := range pkg.GoDoc.Types {
 				pkg.GoDoc.Funcs = append(pkg.GoDoc.Funcs, t.Funcs...)
 				t.Funcs = nil
@@ -94,24 +219,233 @@ func loadPackage(ctx *build.Context, importPath string, srcDir string, flags int
 
 	if flags&loadPackageExamples != 0 {
 		for _, name := range append(pkg.Build.TestGoFiles, pkg.Build.XTestGoFiles...) {
+			if err := cctx.Err(); err != nil {
+				return nil, fmt.Errorf("loading %s: %w", importPath, err)
+			}
 			file, err := pkg.parseFile(ctx, name)
 			if err != nil {
 				pkg.Errors = append(pkg.Errors, err)
-				continue
 			}
-			pkg.Examples = append(pkg.Examples, godoc.Examples(file)...)
+			if file != nil {
+				for _, e := range godoc.Examples(file) {
+					if pkg.ExampleFiles == nil {
+						pkg.ExampleFiles = make(map[*godoc.Example]string)
+					}
+					pkg.ExampleFiles[e] = name
+					pkg.Examples = append(pkg.Examples, e)
+				}
+			}
 		}
 	}
 
 	return pkg, nil
 }
 
+// importPackage imports the package named by importPath. Packages in the
+// module containing srcDir are imported from the module directory. If a
+// replace directive in the go.mod file for srcDir points the package to a
+// local directory, then the package is imported from that directory. If the
+// module for srcDir builds with its vendor directory, then vendored packages
+// are imported from the vendor directory. Otherwise, the vendor directory is
+// ignored and packages not found in GOPATH are imported from the module
+// cache.
+func importPackage(ctx *build.Context, importPath string, srcDir string) (*build.Package, error) {
+	mode := build.ImportComment
+	if !build.IsLocalImport(importPath) {
+		if dir, ok := moduleDir(srcDir, importPath); ok {
+			bpkg, err := ctx.ImportDir(dir, build.ImportComment)
+			if bpkg != nil {
+				bpkg.ImportPath = importPath
+			}
+			return bpkg, err
+		}
+		if dir, ok := replaceDir(srcDir, importPath); ok {
+			bpkg, err := ctx.ImportDir(dir, build.ImportComment)
+			if bpkg != nil {
+				bpkg.ImportPath = importPath
+			}
+			return bpkg, err
+		}
+		if dir, ok := vendorDir(srcDir, importPath); ok {
+			bpkg, err := ctx.ImportDir(dir, build.ImportComment)
+			if bpkg != nil {
+				bpkg.ImportPath = importPath
+			}
+			return bpkg, err
+		}
+		if findModFile(srcDir) != "" {
+			mode |= build.IgnoreVendor
+		}
+	}
+	bpkg, err := ctx.Import(importPath, srcDir, mode)
+	if err == nil || build.IsLocalImport(importPath) || (bpkg != nil && bpkg.Dir != "") {
+		return bpkg, err
+	}
+
+	// The package is not in GOPATH. Look for the package in the module
+	// cache.
+
+	for _, root := range filepath.SplitList(ctx.GOPATH) {
+		if dir, ok := cachedModuleDir(filepath.Join(root, "pkg", "mod"), importPath); ok {
+			if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+				bpkg, err := ctx.ImportDir(dir, build.ImportComment)
+				if bpkg != nil {
+					bpkg.ImportPath = importPath
+				}
+				return bpkg, err
+			}
+		}
+	}
+	return bpkg, newError(errorPackageNotFound, "cannot find package %q in GOPATH or the module cache; download it with \"go get %s\"", importPath, importPath)
+}
+
+// importUses returns the position of the first use of each import in files.
+// The files are searched in name order.
+func importUses(fset *token.FileSet, files map[string]*ast.File) map[string]token.Position {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	uses := make(map[string]token.Position)
+	for _, name := range names {
+		ast.Inspect(files[name], func(n ast.Node) bool {
+			se, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			x, ok := se.X.(*ast.Ident)
+			if !ok || x.Obj == nil || x.Obj.Kind != ast.Pkg {
+				return true
+			}
+			spec, ok := x.Obj.Decl.(*ast.ImportSpec)
+			if !ok {
+				return true
+			}
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+				if _, ok := uses[path]; !ok {
+					uses[path] = fset.Position(se.Pos())
+				}
+			}
+			return false
+		})
+	}
+	for _, name := range names {
+		for _, spec := range files[name].Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+				if _, ok := uses[path]; !ok {
+					uses[path] = fset.Position(spec.Pos())
+				}
+			}
+		}
+	}
+	return uses
+}
+
+// importPrimaryPackage restricts bpkg to the files for the primary package
+// in a directory containing more than one package. The primary package is
+// the package with the same name as the directory or the first package not
+// named main.
+func importPrimaryPackage(ctx *build.Context, bpkg *build.Package, e *build.MultiplePackageError) (*build.Package, error) {
+	return selectPackageFiles(ctx, bpkg, e.Dir, e.Packages, true)
+}
+
+// importAllFiles returns a copy of bpkg with the files in the package
+// directory for the primary package, ignoring build constraints. It is used
+// to document directories where the build fails or where build constraints
+// exclude all files. An error is returned if the directory does not have
+// non-test Go files.
+func importAllFiles(ctx *build.Context, bpkg *build.Package) (*build.Package, error) {
+	if bpkg == nil || bpkg.Dir == "" {
+		return nil, errors.New("no package directory")
+	}
+	fis, err := buildutil.ReadDir(ctx, bpkg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	seen := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, fi := range fis {
+		fname := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(fname, ".go") || strings.HasSuffix(fname, "_test.go") {
+			continue
+		}
+		f, err := buildutil.ParseFile(fset, ctx, nil, bpkg.Dir, fname, parser.PackageClauseOnly)
+		if err != nil || seen[f.Name.Name] {
+			continue
+		}
+		seen[f.Name.Name] = true
+		names = append(names, f.Name.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", bpkg.Dir)
+	}
+	c := *bpkg
+	return selectPackageFiles(ctx, &c, bpkg.Dir, names, false)
+}
+
+// selectPackageFiles sets the files of bpkg to the files in dir for the
+// primary package of the package names. If match is true, then files
+// excluded by build constraints are skipped.
+func selectPackageFiles(ctx *build.Context, bpkg *build.Package, dir string, names []string, match bool) (*build.Package, error) {
+	name := names[0]
+	for _, n := range names {
+		if n == filepath.Base(dir) {
+			name = n
+			break
+		}
+		if name == "main" {
+			name = n
+		}
+	}
+
+	fis, err := buildutil.ReadDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	bpkg.Name = name
+	bpkg.GoFiles = nil
+	bpkg.CgoFiles = nil
+	bpkg.TestGoFiles = nil
+	bpkg.XTestGoFiles = nil
+	fset := token.NewFileSet()
+	for _, fi := range fis {
+		fname := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(fname, ".go") {
+			continue
+		}
+		if ok, err := ctx.MatchFile(dir, fname); match && (err != nil || !ok) {
+			continue
+		}
+		f, err := buildutil.ParseFile(fset, ctx, nil, dir, fname, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		switch n := f.Name.Name; {
+		case strings.HasSuffix(fname, "_test.go") && n == name:
+			bpkg.TestGoFiles = append(bpkg.TestGoFiles, fname)
+		case strings.HasSuffix(fname, "_test.go") && n == name+"_test":
+			bpkg.XTestGoFiles = append(bpkg.XTestGoFiles, fname)
+		case !strings.HasSuffix(fname, "_test.go") && n == name:
+			bpkg.GoFiles = append(bpkg.GoFiles, fname)
+		}
+	}
+	return bpkg, nil
+}
+
 type byFuncName []*godoc.Func
 
 func (s byFuncName) Len() int           { return len(s) }
 func (s byFuncName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s byFuncName) Less(i, j int) bool { return s[i].Name < s[j].Name }
 
+// parseFile parses the named file in the package directory. If the file has
+// syntax errors, then the partial AST for the file is returned with an error
+// noting that the file was partially parsed. A nil AST is returned if the
+// file cannot be read or if the package clause cannot be parsed.
 func (pkg *pkg) parseFile(ctx *build.Context, name string) (*ast.File, error) {
 	f, err := ctx.OpenFile(ctx.JoinPath(pkg.Build.Dir, name))
 	if err != nil {
@@ -128,7 +462,17 @@ func (pkg *pkg) parseFile(ctx *build.Context, name string) (*ast.File, error) {
 			p[i] = ' '
 		}
 	}
-	return parser.ParseFile(pkg.FSet, name, p, parser.ParseComments)
+	file, err := parser.ParseFile(pkg.FSet, name, p, parser.ParseComments|parser.AllErrors)
+	if file != nil {
+		pkg.addEmbeds(file)
+	}
+	if err != nil {
+		if file == nil || file.Name == nil || file.Name.Name == "" {
+			return nil, err
+		}
+		return file, newError(errorParse, "%s partially parsed: %v", name, err)
+	}
+	return file, nil
 }
 
 var linePat = regexp.MustCompile(`(?m)^//line .*$`)
@@ -184,13 +528,68 @@ func guessPackageNameFromPath(path string) string {
 	return ""
 }
 
-func untangleDoc(pkg *godoc.Package) {
+// addEmbeds records the //go:embed directives for the variables in file. The
+// directives are found in the doc comments of the declarations, before
+// go/doc removes the comments from the AST.
+func (pkg *pkg) addEmbeds(file *ast.File) {
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range d.Specs {
+			vs := spec.(*ast.ValueSpec)
+			cg := vs.Doc
+			if cg == nil && len(d.Specs) == 1 {
+				cg = d.Doc
+			}
+			patterns := embedPatterns(cg)
+			if len(patterns) == 0 {
+				continue
+			}
+			if pkg.Embeds == nil {
+				pkg.Embeds = make(map[string][]string)
+			}
+			for _, name := range vs.Names {
+				pkg.Embeds[name.Name] = patterns
+			}
+		}
+	}
+}
+
+// embedPatterns returns the patterns in the //go:embed directives of a
+// comment group.
+func embedPatterns(cg *ast.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+	var patterns []string
+	for _, c := range cg.List {
+		if !strings.HasPrefix(c.Text, "//go:embed ") {
+			continue
+		}
+		for _, p := range strings.Fields(c.Text[len("//go:embed "):]) {
+			if q, err := strconv.Unquote(p); err == nil {
+				p = q
+			}
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// untangleDoc returns a copy of pkg with the constants, variables and
+// functions associated with types added to the package lists. The package is
+// not modified because loaded packages are shared through the cache.
+func untangleDoc(pkg *godoc.Package) *godoc.Package {
+	u := *pkg
+	u.Consts = append([]*godoc.Value(nil), pkg.Consts...)
+	u.Vars = append([]*godoc.Value(nil), pkg.Vars...)
+	u.Funcs = append([]*godoc.Func(nil), pkg.Funcs...)
 	for _, t := range pkg.Types {
-		pkg.Consts = append(pkg.Consts, t.Consts...)
-		t.Consts = nil
-		pkg.Vars = append(pkg.Vars, t.Vars...)
-		t.Vars = nil
-		pkg.Funcs = append(pkg.Funcs, t.Funcs...)
-		t.Funcs = nil
+		u.Consts = append(u.Consts, t.Consts...)
+		u.Vars = append(u.Vars, t.Vars...)
+		u.Funcs = append(u.Funcs, t.Funcs...)
 	}
+	return &u
 }
@@ -0,0 +1,193 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	stdcontext "context"
+	"errors"
+	"go/build"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestMultiplePackages(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/multi", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.GoDoc == nil || pkg.GoDoc.Name != "multi" {
+		t.Fatalf("primary package not documented")
+	}
+	if len(pkg.GoDoc.Funcs) != 1 || pkg.GoDoc.Funcs[0].Name != "Hello" {
+		t.Errorf("funcs = %v, want Hello", pkg.GoDoc.Funcs)
+	}
+	if len(pkg.Errors) != 1 {
+		t.Fatalf("errors = %v, want one error", pkg.Errors)
+	}
+	if _, ok := pkg.Errors[0].(*build.MultiplePackageError); !ok {
+		t.Errorf("error = %T, want *build.MultiplePackageError", pkg.Errors[0])
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/syntax", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, f := range pkg.GoDoc.Funcs {
+		names[f.Name] = true
+	}
+	for _, name := range []string{"Good", "BeforeError", "AfterError"} {
+		if !names[name] {
+			t.Errorf("%s not documented", name)
+		}
+	}
+	if len(pkg.Errors) != 1 || !strings.HasPrefix(pkg.Errors[0].Error(), "broken.go partially parsed: ") {
+		t.Errorf("errors = %v, want partially parsed error for broken.go", pkg.Errors)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+
+	linux, windows := ctx.Build, ctx.Build
+	linux.GOOS, windows.GOOS = "linux", "windows"
+	if newLoadKey(&linux, "os", cwd, loadPackageDoc) == newLoadKey(&windows, "os", cwd, loadPackageDoc) {
+		t.Error("keys equal for different GOOS")
+	}
+	linuxPkg, err := loadPackage(&linux, "os", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windowsPkg, err := loadPackage(&windows, "os", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(linuxPkg.Build.GoFiles, " ") == strings.Join(windowsPkg.Build.GoFiles, " ") {
+		t.Errorf("same files loaded for linux and windows: %v", linuxPkg.Build.GoFiles)
+	}
+
+	if newLoadKey(&linux, "os", cwd, loadPackageDoc) == newLoadKey(&linux, "os", cwd, loadPackageDoc|loadPackageUnexported) {
+		t.Error("keys equal for different flags")
+	}
+
+	ab, ba, none := linux, linux, linux
+	ab.BuildTags = []string{"a", "b"}
+	ba.BuildTags = []string{"b", "a"}
+	if newLoadKey(&ab, "os", cwd, 0) != newLoadKey(&ba, "os", cwd, 0) {
+		t.Error("keys differ for the same tags in a different order")
+	}
+	if newLoadKey(&ab, "os", cwd, 0) == newLoadKey(&none, "os", cwd, 0) {
+		t.Error("keys equal for different tags")
+	}
+}
+
+func TestLoadPackageCanceled(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	cctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+	if _, err := loadPackageContext(cctx, &ctx.Build, "net/http", cwd, loadPackageDoc); !errors.Is(err, stdcontext.Canceled) {
+		t.Errorf("loadPackageContext returned %v, want %v", err, stdcontext.Canceled)
+	}
+}
+
+func TestEmbeds(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/goembed", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"Greeting": {"hello.txt"},
+		"Static":   {"static/*.css", "hello.txt"},
+	}
+	if !reflect.DeepEqual(pkg.Embeds, want) {
+		t.Errorf("embeds = %v, want %v", pkg.Embeds, want)
+	}
+}
+
+func TestUnsafe(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	pkg, err := loadPackage(&ctx.Build, "unsafe", "", loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.GoDoc == nil {
+		t.Fatal("unsafe not documented")
+	}
+	funcs := make(map[string]bool)
+	for _, f := range pkg.GoDoc.Funcs {
+		funcs[f.Name] = true
+	}
+	for _, name := range []string{"Add", "Alignof", "Offsetof", "Sizeof", "Slice", "SliceData"} {
+		if !funcs[name] {
+			t.Errorf("function %s not found", name)
+		}
+	}
+	types := make(map[string]bool)
+	for _, typ := range pkg.GoDoc.Types {
+		types[typ.Name] = true
+		if len(typ.Funcs) != 0 {
+			t.Errorf("type %s has %d functions, want 0", typ.Name, len(typ.Funcs))
+		}
+	}
+	for _, name := range []string{"ArbitraryType", "IntegerType", "Pointer"} {
+		if !types[name] {
+			t.Errorf("type %s not found", name)
+		}
+	}
+
+	d, err := printDoc(&ctx.Build, bufNamePrefix+"unsafe", "", &docOptions{Detail: detailDoc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := string(d.Bytes()); !strings.Contains(p, "\nfunc Sizeof(x ArbitraryType) uintptr\n") {
+		t.Errorf("Sizeof not found in page:\n%s", p)
+	}
+}
+
+var importUsesTests = []struct {
+	path      string
+	file      string
+	line, col int
+}{
+	{"strings", "a.go", 12, 9},
+	{"fmt", "a.go", 12, 25},
+	{"unsafe", "a.go", 7, 2},
+}
+
+func TestImportUses(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	pkg, err := loadPackage(&ctx.Build, "./testdata/uses", cwd, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.ImportUses) != len(importUsesTests) {
+		t.Errorf("got %d import uses, want %d", len(pkg.ImportUses), len(importUsesTests))
+	}
+	for _, tt := range importUsesTests {
+		pos, ok := pkg.ImportUses[tt.path]
+		if !ok {
+			t.Errorf("use of %s not found", tt.path)
+			continue
+		}
+		if pos.Filename != tt.file || pos.Line != tt.line || pos.Column != tt.col {
+			t.Errorf("use of %s at %s:%d:%d, want %s:%d:%d", tt.path, pos.Filename, pos.Line, pos.Column, tt.file, tt.line, tt.col)
+		}
+	}
+}
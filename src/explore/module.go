@@ -0,0 +1,284 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// findModFile returns the path of the go.mod file for the directory dir or
+// "" if dir is not in a module.
+func findModFile(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for {
+		fname := filepath.Join(dir, "go.mod")
+		if fi, err := os.Stat(fname); err == nil && !fi.IsDir() {
+			return fname
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// replaceDir returns the local directory for importPath as specified by a
+// replace directive in the go.mod file for srcDir.
+func replaceDir(srcDir, importPath string) (string, bool) {
+	fname := findModFile(srcDir)
+	if fname == "" {
+		return "", false
+	}
+	p, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return "", false
+	}
+	f, err := modfile.Parse(fname, p, nil)
+	if err != nil {
+		return "", false
+	}
+	for _, r := range f.Replace {
+		if !modfile.IsDirectoryPath(r.New.Path) {
+			continue
+		}
+		if importPath != r.Old.Path && !strings.HasPrefix(importPath, r.Old.Path+"/") {
+			continue
+		}
+		dir := r.New.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(fname), dir)
+		}
+		return filepath.Join(dir, filepath.FromSlash(importPath[len(r.Old.Path):])), true
+	}
+	return "", false
+}
+
+// vendorDir returns the directory for importPath in the vendor directory of
+// the module for srcDir. The vendor directory is used when the go command
+// would use it to build the module.
+func vendorDir(srcDir, importPath string) (string, bool) {
+	fname := findModFile(srcDir)
+	if fname == "" || !vendorEnabled(fname) {
+		return "", false
+	}
+	dir := filepath.Join(filepath.Dir(fname), "vendor", filepath.FromSlash(importPath))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// vendorEnabled reports whether the go command uses the vendor directory
+// for the module with go.mod file fname. The -mod flag in GOFLAGS selects
+// the vendor directory (-mod=vendor) or the module cache (-mod=mod and
+// -mod=readonly). Without the flag, the vendor directory is used if it has a
+// modules.txt file and the go.mod file specifies go 1.14 or later.
+func vendorEnabled(fname string) bool {
+	switch modFlag(os.Getenv("GOFLAGS")) {
+	case "vendor":
+		return true
+	case "mod", "readonly":
+		return false
+	}
+	if fi, err := os.Stat(filepath.Join(filepath.Dir(fname), "vendor", "modules.txt")); err != nil || fi.IsDir() {
+		return false
+	}
+	p, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return false
+	}
+	f, err := modfile.ParseLax(fname, p, nil)
+	if err != nil || f.Go == nil {
+		return false
+	}
+	return semver.Compare("v"+f.Go.Version, "v1.14") >= 0
+}
+
+// modFlag returns the value of the -mod flag in goflags or "" if the flag is
+// not set.
+func modFlag(goflags string) string {
+	var mod string
+	for _, f := range strings.Fields(goflags) {
+		f = strings.TrimPrefix(f, "-")
+		if strings.HasPrefix(f, "-mod=") || strings.HasPrefix(f, "mod=") {
+			mod = f[strings.Index(f, "=")+1:]
+		}
+	}
+	return mod
+}
+
+// moduleForDir returns the path and version of the module containing dir.
+// The version is "" for modules outside of the module cache. The path is ""
+// if dir is not in a module or is in the standard library.
+func moduleForDir(dir string) (string, string) {
+	fname := findModFile(dir)
+	if fname == "" {
+		return "", ""
+	}
+	p, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return "", ""
+	}
+	modPath := modfile.ModulePath(p)
+	if modPath == "std" || modPath == "cmd" {
+		return "", ""
+	}
+	var version string
+	if base := filepath.Base(filepath.Dir(fname)); strings.HasPrefix(base, path.Base(modPath)+"@") {
+		version = base[strings.LastIndex(base, "@")+1:]
+	}
+	return modPath, version
+}
+
+// moduleDir returns the directory for importPath in the module containing
+// srcDir. The result is false if importPath is not a package directory in
+// the module.
+func moduleDir(srcDir, importPath string) (string, bool) {
+	fname := findModFile(srcDir)
+	if fname == "" {
+		return "", false
+	}
+	modPath, _ := moduleForDir(srcDir)
+	if modPath == "" || (importPath != modPath && !strings.HasPrefix(importPath, modPath+"/")) {
+		return "", false
+	}
+	dir := filepath.Join(filepath.Dir(fname), filepath.FromSlash(importPath[len(modPath):]))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() || findModFile(dir) != fname {
+		return "", false
+	}
+	return dir, true
+}
+
+// moduleImportPath returns the import path of the package in dir formed from
+// the path of the module containing dir and the path of dir relative to the
+// module root. The result is "" if dir is not in a module.
+func moduleImportPath(dir string) string {
+	fname := findModFile(dir)
+	if fname == "" {
+		return ""
+	}
+	modPath, _ := moduleForDir(dir)
+	if modPath == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Dir(fname), dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if rel == "." {
+		return modPath
+	}
+	return modPath + "/" + filepath.ToSlash(rel)
+}
+
+// cachedModuleDir returns the directory for importPath in the module cache
+// rooted at cache. The longest module path prefix of importPath found in the
+// cache is used. If the cache has more than one version of the module, then
+// the directory for the highest version is returned.
+func cachedModuleDir(cache, importPath string) (string, bool) {
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		escaped, err := module.EscapePath(modPath)
+		if err != nil {
+			return "", false
+		}
+		matches, _ := filepath.Glob(filepath.Join(cache, filepath.FromSlash(escaped)) + "@*")
+		var dir, version string
+		for _, m := range matches {
+			v := m[strings.LastIndex(m, "@")+1:]
+			if fi, err := os.Stat(m); err != nil || !fi.IsDir() || !semver.IsValid(v) {
+				continue
+			}
+			if dir == "" || semver.Compare(v, version) > 0 {
+				dir, version = m, v
+			}
+		}
+		if dir != "" {
+			return filepath.Join(dir, filepath.FromSlash(importPath[len(modPath):])), true
+		}
+	}
+	return "", false
+}
+
+// moduleVersionDir returns the directory for version of the module modPath
+// in the module cache rooted at cache.
+func moduleVersionDir(cache, modPath, version string) (string, bool) {
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return "", false
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", false
+	}
+	dir := filepath.Join(cache, filepath.FromSlash(escaped)+"@"+escapedVersion)
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// moduleCacheDir returns the module cache directory: $GOMODCACHE or the
+// pkg/mod directory in the first GOPATH entry. The result is "" if neither
+// is set.
+func moduleCacheDir(ctx *build.Context) string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if roots := filepath.SplitList(ctx.GOPATH); len(roots) > 0 && roots[0] != "" {
+		return filepath.Join(roots[0], "pkg", "mod")
+	}
+	return ""
+}
+
+// cachedModulePaths returns the sorted paths of the modules in the module
+// cache rooted at cache. A module with more than one version is returned
+// once. The download cache in the cache directory is skipped.
+func cachedModulePaths(cache string) []string {
+	if cache == "" {
+		return nil
+	}
+	m := map[string]bool{}
+	var walk func(dir, prefix string)
+	walk = func(dir, prefix string) {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, fi := range fis {
+			n := fi.Name()
+			if !fi.IsDir() || strings.HasPrefix(n, ".") || (prefix == "" && n == "cache") {
+				continue
+			}
+			if i := strings.LastIndex(n, "@"); i >= 0 {
+				if modPath, err := module.UnescapePath(prefix + n[:i]); err == nil {
+					m[modPath] = true
+				}
+				continue
+			}
+			walk(filepath.Join(dir, n), prefix+n+"/")
+		}
+	}
+	walk(cache, "")
+	paths := make([]string, 0, len(m))
+	for modPath := range m {
+		paths = append(paths, modPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestReplace(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	srcDir, err := filepath.Abs("testdata/replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := loadPackage(&ctx.Build, "example.com/dep", srcDir, loadPackageDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(srcDir, "dep"); pkg.Build.Dir != want {
+		t.Errorf("dir = %q, want %q", pkg.Build.Dir, want)
+	}
+	if pkg.Build.ImportPath != "example.com/dep" {
+		t.Errorf("import path = %q, want %q", pkg.Build.ImportPath, "example.com/dep")
+	}
+	if pkg.GoDoc == nil || len(pkg.GoDoc.Funcs) != 1 || pkg.GoDoc.Funcs[0].Name != "Replaced" {
+		t.Errorf("replacement package not documented")
+	}
+}
+
+var moduleForDirTests = []struct {
+	dir, path, version string
+}{
+	{"testdata/replace", "example.com/main", ""},
+	{"testdata/replace/dep", "example.com/dep", ""},
+	{"testdata/mod/example.com/cached@v1.2.3/sub", "example.com/cached", "v1.2.3"},
+}
+
+func TestModuleForDir(t *testing.T) {
+	for _, tt := range moduleForDirTests {
+		dir, err := filepath.Abs(tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path, version := moduleForDir(dir)
+		if path != tt.path || version != tt.version {
+			t.Errorf("moduleForDir(%q) = %q, %q, want %q, %q", tt.dir, path, version, tt.path, tt.version)
+		}
+	}
+}
+
+var cachedModuleDirTests = []struct {
+	importPath, dir string
+	ok              bool
+}{
+	{"example.com/cached", "example.com/cached@v1.10.0", true},
+	{"example.com/cached/sub", "example.com/cached@v1.10.0/sub", true},
+	{"example.com/Upper/pkg", "example.com/!upper@v0.1.0/pkg", true},
+	{"example.com/missing", "", false},
+}
+
+func TestCachedModuleDir(t *testing.T) {
+	for _, tt := range cachedModuleDirTests {
+		dir, ok := cachedModuleDir("testdata/mod", tt.importPath)
+		want := ""
+		if tt.dir != "" {
+			want = filepath.Join("testdata/mod", filepath.FromSlash(tt.dir))
+		}
+		if dir != want || ok != tt.ok {
+			t.Errorf("cachedModuleDir(%q) = %q, %v, want %q, %v", tt.importPath, dir, ok, want, tt.ok)
+		}
+	}
+}
+
+var modFlagTests = []struct {
+	goflags, mod string
+}{
+	{"", ""},
+	{"-mod=vendor", "vendor"},
+	{"--mod=mod", "mod"},
+	{"-tags=x -mod=readonly", "readonly"},
+	{"-mod=vendor -mod=mod", "mod"},
+	{"-modcacherw", ""},
+}
+
+func TestModFlag(t *testing.T) {
+	for _, tt := range modFlagTests {
+		if mod := modFlag(tt.goflags); mod != tt.mod {
+			t.Errorf("modFlag(%q) = %q, want %q", tt.goflags, mod, tt.mod)
+		}
+	}
+}
+
+var vendorTests = []struct {
+	goflags string
+	dir     string
+}{
+	{"", "testdata/vendored/vendor/example.com/cached"},
+	{"-mod=vendor", "testdata/vendored/vendor/example.com/cached"},
+	{"-mod=mod", "testdata/mod/example.com/cached@v1.10.0"},
+	{"-mod=readonly", "testdata/mod/example.com/cached@v1.10.0"},
+}
+
+func TestVendor(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "vigor-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	cache, err := filepath.Abs("testdata/mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(gopath, "pkg"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(cache, filepath.Join(gopath, "pkg", "mod")); err != nil {
+		t.Fatal(err)
+	}
+	srcDir, err := filepath.Abs("testdata/vendored")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Setenv("GOFLAGS", os.Getenv("GOFLAGS"))
+	ctx := context.Get(&context.Env{}).Build
+	ctx.GOPATH = gopath
+	for _, tt := range vendorTests {
+		os.Setenv("GOFLAGS", tt.goflags)
+		pkg, err := loadPackage(&ctx, "example.com/cached", srcDir, 0)
+		if err != nil {
+			t.Errorf("GOFLAGS=%s: %v", tt.goflags, err)
+			continue
+		}
+		want, _ := filepath.Abs(tt.dir)
+		if dir, _ := filepath.EvalSymlinks(pkg.Build.Dir); dir != want {
+			t.Errorf("GOFLAGS=%s: dir = %q, want %q", tt.goflags, dir, want)
+		}
+		if pkg.Build.ImportPath != "example.com/cached" {
+			t.Errorf("GOFLAGS=%s: import path = %q, want %q", tt.goflags, pkg.Build.ImportPath, "example.com/cached")
+		}
+	}
+}
+
+var moduleVersionDirTests = []struct {
+	modPath, version string
+	dir              string
+	ok               bool
+}{
+	{"example.com/cached", "v1.2.3", "example.com/cached@v1.2.3", true},
+	{"example.com/Upper", "v0.1.0", "example.com/!upper@v0.1.0", true},
+	{"example.com/cached", "v1.2.4", "", false},
+	{"example.com/missing", "v1.0.0", "", false},
+}
+
+func TestModuleVersionDir(t *testing.T) {
+	for _, tt := range moduleVersionDirTests {
+		dir, ok := moduleVersionDir("testdata/mod", tt.modPath, tt.version)
+		want := ""
+		if tt.dir != "" {
+			want = filepath.Join("testdata/mod", filepath.FromSlash(tt.dir))
+		}
+		if dir != want || ok != tt.ok {
+			t.Errorf("moduleVersionDir(%q, %q) = %q, %v, want %q, %v", tt.modPath, tt.version, dir, ok, want, tt.ok)
+		}
+	}
+}
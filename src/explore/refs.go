@@ -0,0 +1,200 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"golang.org/x/tools/go/buildutil"
+)
+
+// progressDirs is the number of directories searched between reports of
+// progress in long searches.
+const progressDirs = 500
+
+// walkSourceDirs calls fn with dir and its entries for dir and each of its
+// subdirectories in name order. Directories named testdata and vendor and
+// directories with names starting with "." or "_" are skipped. If module is
+// true, then subdirectories containing a go.mod file are skipped.
+func walkSourceDirs(ctx *build.Context, dir string, module bool, fn func(dir string, fis []os.FileInfo)) {
+	fis, err := buildutil.ReadDir(ctx, dir)
+	if err != nil {
+		return
+	}
+	fn(dir, fis)
+	for _, fi := range fis {
+		n := fi.Name()
+		if !fi.IsDir() || n == "testdata" || n == "vendor" ||
+			strings.HasPrefix(n, ".") || strings.HasPrefix(n, "_") {
+			continue
+		}
+		sub := buildutil.JoinPath(ctx, dir, n)
+		if module && buildutil.FileExists(ctx, buildutil.JoinPath(ctx, sub, "go.mod")) {
+			continue
+		}
+		walkSourceDirs(ctx, sub, module, fn)
+	}
+}
+
+// refScanner finds the selector expressions referring to an exported
+// top-level name in a package.
+type refScanner struct {
+	ctx        *build.Context
+	importPath string
+	pkgName    string // default name of the package in imports
+	name       string
+
+	// progress is called with the number of directories searched.
+	progress func(n int)
+
+	ndirs int
+	qfl   []*nvim.QuickfixError
+}
+
+// scanDir appends the references in the Go files in dir with entries fis to
+// the quickfix list.
+func (s *refScanner) scanDir(dir string, fis []os.FileInfo) {
+	s.ndirs++
+	if s.progress != nil && s.ndirs%progressDirs == 0 {
+		s.progress(s.ndirs)
+	}
+	fset := token.NewFileSet()
+	for _, fi := range fis {
+		n := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(n, ".go") {
+			continue
+		}
+		if ok, err := s.ctx.MatchFile(dir, n); err != nil || !ok {
+			continue
+		}
+		s.scanFile(fset, dir, n)
+	}
+}
+
+// scanFile appends the references in the file dir/fname to the quickfix
+// list.
+func (s *refScanner) scanFile(fset *token.FileSet, dir, fname string) {
+	fname = buildutil.JoinPath(s.ctx, dir, fname)
+	r, err := buildutil.OpenFile(s.ctx, fname)
+	if err != nil {
+		return
+	}
+	src, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return
+	}
+
+	// Check the imports before parsing the whole file.
+	f, err := parser.ParseFile(fset, fname, src, parser.ImportsOnly)
+	if err != nil {
+		return
+	}
+	local := s.localName(f)
+	if local == "" {
+		return
+	}
+
+	f, _ = parser.ParseFile(fset, fname, src, 0)
+	if f == nil {
+		return
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != s.name {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || x.Name != local || x.Obj != nil {
+			return true
+		}
+		p := fset.Position(sel.Pos())
+		s.qfl = append(s.qfl, &nvim.QuickfixError{
+			FileName: fname,
+			LNum:     p.Line,
+			Col:      p.Column,
+			Text:     sourceLine(src, p.Offset),
+		})
+		return true
+	})
+}
+
+// localName returns the name used for the package in file f or "" if the
+// file does not import the package with a name that can be used in a
+// selector expression.
+func (s *refScanner) localName(f *ast.File) string {
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || p != s.importPath {
+			continue
+		}
+		if spec.Name == nil {
+			return s.pkgName
+		}
+		if n := spec.Name.Name; n != "_" && n != "." {
+			return n
+		}
+	}
+	return ""
+}
+
+// sourceLine returns the line in src containing the byte offset without
+// leading and trailing white space.
+func sourceLine(src []byte, offset int) string {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := bytes.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+	return string(bytes.TrimSpace(src[start:end]))
+}
+
+// refPackage returns the package for the package specification spec as
+// described in resolvePackageSpec. A package specified by a local import
+// path in a module is returned with its import path in the module so that
+// the imports of the package can be matched.
+func refPackage(ctx *build.Context, cwd, bufDir string, src io.Reader, spec string) (*build.Package, error) {
+	bpkg, err := importPackage(ctx, resolvePackageSpec(ctx, cwd, bufDir, src, spec), cwd)
+	if err != nil {
+		return nil, err
+	}
+	if build.IsLocalImport(bpkg.ImportPath) {
+		if p := moduleImportPath(bpkg.Dir); p != "" {
+			bpkg.ImportPath = p
+		}
+	}
+	return bpkg, nil
+}
+
+// findRefs returns the selector expressions referring to the exported
+// top-level name in the package importPath as quickfix errors. The Go files
+// matching ctx in the directories dirs and their subdirectories are
+// searched as described in walkSourceDirs.
+func findRefs(ctx *build.Context, dirs []string, module bool, importPath, pkgName, name string, progress func(int)) []*nvim.QuickfixError {
+	s := &refScanner{
+		ctx:        ctx,
+		importPath: importPath,
+		pkgName:    pkgName,
+		name:       name,
+		progress:   progress,
+	}
+	for _, dir := range dirs {
+		walkSourceDirs(ctx, filepath.Clean(dir), module, s.scanDir)
+	}
+	return s.qfl
+}
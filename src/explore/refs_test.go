@@ -0,0 +1,87 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestFindRefs(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	dir, _ := filepath.Abs(filepath.Join("testdata", "refs"))
+	for _, tt := range []struct {
+		name   string
+		module bool
+		want   []string
+	}{
+		{"F", true, []string{"b/b.go:6:2 a.F()", "b/b.go:8:2 a.F()", "c/c.go:6:2 x.F()"}},
+		{"V", true, []string{"b/b.go:7:6 _ = a.V"}},
+		{"V", false, []string{"b/b.go:7:6 _ = a.V", "sub/sub.go:5:9 var v = a.V"}},
+	} {
+		var got []string
+		for _, qfe := range findRefs(&ctx.Build, []string{dir}, tt.module, "example.com/a", "a", tt.name, nil) {
+			rel, _ := filepath.Rel(dir, qfe.FileName)
+			got = append(got, fmt.Sprintf("%s:%d:%d %s", filepath.ToSlash(rel), qfe.LNum, qfe.Col, qfe.Text))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findRefs(%s, module=%v) = %q, want %q", tt.name, tt.module, got, tt.want)
+		}
+	}
+}
+
+// writeModule writes the files in the map from slash separated names to
+// contents to a temporary directory and returns the directory.
+func writeModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "vigor-module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindRefsInModule(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/m\n",
+		"a/a.go": "package a\n\nfunc F() {}\n",
+		"b/b.go": "package b\n\nimport \"example.com/m/a\"\n\nfunc f() { a.F() }\n",
+	})
+	defer os.RemoveAll(dir)
+
+	ctx := context.Get(&context.Env{})
+	for _, spec := range []string{"./a", "example.com/m/a"} {
+		bpkg, err := refPackage(&ctx.Build, dir, "", nil, spec)
+		if err != nil {
+			t.Errorf("refPackage(%q) returned error %v", spec, err)
+			continue
+		}
+		if bpkg.ImportPath != "example.com/m/a" {
+			t.Errorf("refPackage(%q) import path = %q, want %q", spec, bpkg.ImportPath, "example.com/m/a")
+		}
+		var got []string
+		for _, qfe := range findRefs(&ctx.Build, []string{dir}, true, bpkg.ImportPath, bpkg.Name, "F", nil) {
+			rel, _ := filepath.Rel(dir, qfe.FileName)
+			got = append(got, fmt.Sprintf("%s:%d:%d %s", filepath.ToSlash(rel), qfe.LNum, qfe.Col, qfe.Text))
+		}
+		if want := []string{"b/b.go:5:12 func f() { a.F() }"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("findRefs(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
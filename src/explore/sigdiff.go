@@ -0,0 +1,45 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"fmt"
+	"go/build"
+
+	"github.com/garyburd/vigor/src/doc"
+)
+
+// printSignature prints the declaration of symbol in the package with the
+// given import path. The declaration is preceded by the package clause so
+// that the two sides of a :Gosigdiff are distinguishable.
+func printSignature(ctx *build.Context, cwd, importPath, symbol string) (*doc.Doc, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.GoDoc == nil {
+		return nil, fmt.Errorf("no Go files in %s", pkg.Build.ImportPath)
+	}
+	decl := findDecl(pkg, symbol)
+	if decl == nil {
+		return nil, fmt.Errorf("%s not found in %s", symbol, pkg.Build.ImportPath)
+	}
+	p := docPrinter{
+		pkg:        pkg,
+		Doc:        doc.NewDoc(),
+		ctx:        ctx,
+		importPath: importPath,
+		options:    &docOptions{},
+	}
+	p.PushHighlight(declGroup)
+	p.WriteString("package ")
+	p.WriteLinkAnchor(p.GoDoc.Name, bufNamePrefix+p.Build.ImportPath, "")
+	p.PushHighlight(commentGroup)
+	fmt.Fprintf(p.Doc, " // import \"%s\"\n\n", p.Build.ImportPath)
+	p.PopHighlight()
+	p.PopHighlight()
+	p.printDecl(decl, "")
+	return p.Doc, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/garyburd/vigor/src/doc"
+)
+
+// snippetDir is the directory where snippets are written for loading. The
+// directory is reused so that links to the snippet source remain valid until
+// the next snippet is documented.
+var snippetDir = filepath.Join(os.TempDir(), "vigor-snippet")
+
+// printSnippet prints the documentation for a snippet of Go source code. A
+// package clause is added to the snippet if it does not have one.
+func printSnippet(ctx *build.Context, src []byte, options *docOptions) (*doc.Doc, error) {
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly); err != nil {
+		src = append([]byte("package snippet\n\n"), src...)
+	}
+	if err := os.RemoveAll(snippetDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(snippetDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(snippetDir, "snippet.go"), src, 0600); err != nil {
+		return nil, err
+	}
+	return printDoc(ctx, bufNamePrefix+".", snippetDir, options)
+}
@@ -0,0 +1,201 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	godoc "go/doc"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+// symbol is an entry in the symbol index of a package.
+type symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature"`
+	Synopsis  string `json:"synopsis"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+}
+
+// symbolIndex returns the exported symbols declared in the package with the
+// given import path. Methods are named Type.Method.
+func symbolIndex(ctx *build.Context, cwd, importPath string) ([]*symbol, error) {
+	pkg, err := loadPackage(ctx, importPath, cwd, loadPackageDoc)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.GoDoc == nil {
+		return nil, fmt.Errorf("no Go files in %s", pkg.Build.ImportPath)
+	}
+	return packageSymbols(pkg), nil
+}
+
+// packageSymbols returns the exported symbols declared in pkg. The
+// constants, variables and functions associated with types are listed with
+// the package level declarations of the same kind. The package
+// documentation is not modified.
+func packageSymbols(pkg *pkg) []*symbol {
+	var syms []*symbol
+	add := func(name, kind string, id *ast.Ident, sig ast.Node, doc string) {
+		var buf bytes.Buffer
+		(&printer.Config{Mode: printer.RawFormat}).Fprint(&buf, pkg.FSet, sig)
+		pos := pkg.FSet.Position(id.Pos())
+		syms = append(syms, &symbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: buf.String(),
+			Synopsis:  pkg.GoDoc.Synopsis(doc),
+			File:      filepath.Join(pkg.Build.Dir, pos.Filename),
+			Line:      pos.Line,
+			Column:    pos.Column,
+		})
+	}
+	addFunc := func(name, kind string, d *godoc.Func) {
+		decl := *d.Decl
+		decl.Doc, decl.Body = nil, nil
+		add(name, kind, d.Decl.Name, &decl, d.Doc)
+	}
+	addValues := func(values []*godoc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for _, id := range vs.Names {
+					if !id.IsExported() {
+						continue
+					}
+					sig := &ast.ValueSpec{Names: []*ast.Ident{id}, Type: vs.Type}
+					add(id.Name, v.Decl.Tok.String(), id, &ast.GenDecl{Tok: v.Decl.Tok, Specs: []ast.Spec{sig}}, v.Doc)
+				}
+			}
+		}
+	}
+
+	addValues(pkg.GoDoc.Consts)
+	for _, t := range pkg.GoDoc.Types {
+		addValues(t.Consts)
+	}
+	addValues(pkg.GoDoc.Vars)
+	for _, t := range pkg.GoDoc.Types {
+		addValues(t.Vars)
+	}
+	for _, d := range pkg.GoDoc.Funcs {
+		addFunc(d.Name, "func", d)
+	}
+	for _, t := range pkg.GoDoc.Types {
+		for _, d := range t.Funcs {
+			addFunc(d.Name, "func", d)
+		}
+	}
+	for _, t := range pkg.GoDoc.Types {
+		for _, spec := range t.Decl.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != t.Name {
+				continue
+			}
+			// Elide the fields and methods of struct and interface types.
+			sig := *ts
+			switch ts.Type.(type) {
+			case *ast.StructType:
+				sig.Type = &ast.StructType{Fields: &ast.FieldList{}}
+			case *ast.InterfaceType:
+				sig.Type = &ast.InterfaceType{Methods: &ast.FieldList{}}
+			}
+			sig.Doc, sig.Comment = nil, nil
+			add(t.Name, "type", ts.Name, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&sig}}, t.Doc)
+		}
+		for _, m := range t.Methods {
+			addFunc(t.Name+"."+m.Name, "method", m)
+		}
+	}
+	return syms
+}
+
+// exportingPackages returns the sorted import paths of the packages in the
+// source directories of ctx that declare the exported top-level name.
+// Directories named internal, testdata and vendor and the top-level cmd
+// directory are not searched.
+func exportingPackages(ctx *build.Context, name string) []string {
+	var paths []string
+	for _, src := range ctx.SrcDirs() {
+		paths = appendExporters(paths, ctx, src, "", name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// appendExporters appends the import paths of the packages in dir and its
+// subdirectories that declare name to paths. The import path of dir relative
+// to the source directory src is importPath.
+func appendExporters(paths []string, ctx *build.Context, src, importPath, name string) []string {
+	dir := buildutil.JoinPath(ctx, src, importPath)
+	if declaresName(ctx, dir, name) {
+		paths = append(paths, importPath)
+	}
+	fis, err := buildutil.ReadDir(ctx, dir)
+	if err != nil {
+		return paths
+	}
+	for _, fi := range fis {
+		n := fi.Name()
+		if !fi.IsDir() || n == "internal" || n == "testdata" || n == "vendor" ||
+			strings.HasPrefix(n, ".") || strings.HasPrefix(n, "_") || (importPath == "" && n == "cmd") {
+			continue
+		}
+		paths = appendExporters(paths, ctx, src, path.Join(importPath, n), name)
+	}
+	return paths
+}
+
+// declaresName returns true if the package in dir declares the top-level
+// name. Test files are not examined.
+func declaresName(ctx *build.Context, dir, name string) bool {
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil || bpkg.Name == "main" {
+		return false
+	}
+	fset := token.NewFileSet()
+	for _, fname := range bpkg.GoFiles {
+		f, err := buildutil.ParseFile(fset, ctx, nil, dir, fname, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == name {
+					return true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.Name == name {
+							return true
+						}
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							if id.Name == name {
+								return true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package explore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/vigor/src/context"
+)
+
+func TestSymbolIndex(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	cwd, _ := os.Getwd()
+	syms, err := symbolIndex(&ctx.Build, cwd, "./testdata/links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "testdata", "links", "links.go")
+	want := []symbol{
+		{"Value", "type", "type Value int", "Value is a value.", file, 10, 6},
+		{"Value.String", "method", "func (v Value) String() string", "String implements fmt.Stringer.", file, 13, 16},
+	}
+	if len(syms) != len(want) {
+		t.Fatalf("got %d symbols, want %d", len(syms), len(want))
+	}
+	for i, s := range syms {
+		if *s != want[i] {
+			t.Errorf("symbol %d = %+v, want %+v", i, *s, want[i])
+		}
+	}
+}
+
+func TestExportingPackages(t *testing.T) {
+	ctx := context.Get(&context.Env{})
+	src, _ := filepath.Abs(filepath.Join("testdata", "exporters"))
+	got := appendExporters(nil, &ctx.Build, src, "", "Marshal")
+	want := []string{"a", "c", "c/d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("appendExporters() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+// Package alias has type aliases.
+package alias
+
+import "os"
+
+// Mode is an alias for os.FileMode.
+type Mode = os.FileMode
+
+// Value is a type definition.
+type Value int
+
+// Other is an alias for a type in the package.
+type Other = Value
+
+// Perm returns the permission bits.
+func Perm() Mode { return 0644 }
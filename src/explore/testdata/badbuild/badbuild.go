@@ -0,0 +1,4 @@
+//go:build (linux
+
+// Package badbuild has an invalid build constraint.
+package badbuild
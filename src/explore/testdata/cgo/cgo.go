@@ -0,0 +1,22 @@
+// Package cgo has declarations that use the cgo pseudo-package.
+package cgo
+
+/*
+typedef struct point { int x, y; } point;
+*/
+import "C"
+
+// Point is a C point.
+type Point C.struct_point
+
+// Handle is a C int.
+type Handle C.int
+
+// Open opens the named thing.
+func Open(name *C.char, flags C.int) (Handle, error) { return 0, nil }
+
+// Points is a list of points.
+type Points struct {
+	P     []C.struct_point
+	Count C.size_t
+}
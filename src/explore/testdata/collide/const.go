@@ -0,0 +1,5 @@
+// Package collide declares a constant and a variable with the same name.
+package collide
+
+// X is a constant.
+const X = 1
@@ -0,0 +1,4 @@
+package collide
+
+// X is a variable.
+var X = 2
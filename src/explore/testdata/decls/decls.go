@@ -0,0 +1,26 @@
+// Package decls has one declaration of each kind.
+package decls
+
+// Max is a constant.
+const Max = 10
+
+// Default is a variable.
+var Default = New()
+
+// New returns a value.
+func New() *Value { return &Value{} }
+
+// Value is a struct type.
+type Value struct {
+	// N is a field.
+	N int
+}
+
+// Get is a method.
+func (v *Value) Get() int { return v.N }
+
+// Getter is an interface type.
+type Getter interface {
+	// Get is an interface method.
+	Get() int
+}
@@ -0,0 +1,75 @@
+// Package docgo has all of its package documentation in a doc.go file. The
+// doc.go file contains only the package clause and the package comment.
+//
+// # Section 1
+//
+// This is section 1 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 2
+//
+// This is section 2 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 3
+//
+// This is section 3 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 4
+//
+// This is section 4 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 5
+//
+// This is section 5 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 6
+//
+// This is section 6 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 7
+//
+// This is section 7 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 8
+//
+// This is section 8 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 9
+//
+// This is section 9 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 10
+//
+// This is section 10 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 11
+//
+// This is section 11 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+//
+// # Section 12
+//
+// This is section 12 of the long package overview. The overview is long
+// enough that the documentation page folds everything after the first
+// paragraph.
+package docgo
@@ -0,0 +1,4 @@
+package docgo
+
+// Hello returns a greeting.
+func Hello() string { return "hello" }
@@ -0,0 +1,11 @@
+// Package elide has declarations with long literals.
+package elide
+
+// Long is a long string.
+var Long = "abcdefghijklmnopqrstuvwxyz"
+
+// Table is a long table.
+var Table = []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+// F has a body.
+func F() int { return len(Long) }
@@ -0,0 +1,19 @@
+// Package embed has a type with promoted methods.
+package embed
+
+// Inner is embedded in Outer.
+type Inner struct{}
+
+// Hello is promoted to Outer.
+func (Inner) Hello() {}
+
+// Goodbye is promoted to Outer.
+func (*Inner) Goodbye() {}
+
+// Outer embeds Inner.
+type Outer struct {
+	*Inner
+}
+
+// Own is declared by Outer.
+func (Outer) Own() {}
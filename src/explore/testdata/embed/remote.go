@@ -0,0 +1,21 @@
+package embed
+
+import (
+	"io"
+	"strings"
+)
+
+// Buffered embeds a type from another package.
+type Buffered struct {
+	*strings.Reader
+	Inner
+}
+
+// Len shadows the Len method of strings.Reader.
+func (Buffered) Len() int { return 0 }
+
+// ReadHello embeds an interface from another package.
+type ReadHello interface {
+	io.ReadCloser
+	Hello()
+}
@@ -0,0 +1,21 @@
+// Package enum has typed constants.
+package enum
+
+// Weekday is a day of the week.
+type Weekday int
+
+// Days of the week.
+const (
+	Sunday Weekday = iota
+	Monday
+	Tuesday
+)
+
+// Color is a color name.
+type Color string
+
+// Colors.
+const (
+	Red   Color = "red"
+	Green Color = "green"
+)
@@ -0,0 +1,49 @@
+package examples_test
+
+import (
+	"fmt"
+
+	"github.com/garyburd/vigor/src/explore/testdata/examples"
+)
+
+func ExampleHello() {
+	// Print the greeting.
+	if s := examples.Hello(); s != "" {
+		fmt.Println(s)
+	}
+	// Output: hello
+}
+
+func ExampleHello_unordered() {
+	fmt.Println("b")
+	fmt.Println("a")
+	// Unordered output:
+	// a
+	// b
+}
+
+func ExampleHello_empty() {
+	examples.Hello()
+	// Output:
+}
+
+func ExampleHello_notRun() {
+	fmt.Println(examples.Hello())
+}
+
+func ExampleGreeter() {
+	var g examples.Greeter
+	fmt.Println(g.Greet())
+	// Output: hello
+}
+
+func ExampleGreeter_formal() {
+	var g examples.Greeter
+	fmt.Println(g.Greet() + ", sir")
+	// Output: hello, sir
+}
+
+func ExampleGreeter_Greet() {
+	fmt.Println(examples.Greeter{}.Greet())
+	// Output: hello
+}
@@ -0,0 +1,11 @@
+// Package examples has examples.
+package examples
+
+// Hello returns a greeting.
+func Hello() string { return "hello" }
+
+// Greeter greets.
+type Greeter struct{}
+
+// Greet returns a greeting.
+func (Greeter) Greet() string { return "hello" }
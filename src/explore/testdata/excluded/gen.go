@@ -0,0 +1,9 @@
+//go:build ignore
+
+// Gen generates the tables.
+package main
+
+// Table is the generated table.
+var Table = []int{1, 2, 3}
+
+func main() {}
@@ -0,0 +1,4 @@
+package a
+
+// Marshal returns v.
+func Marshal(v int) int { return v }
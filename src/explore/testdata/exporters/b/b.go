@@ -0,0 +1,7 @@
+package b
+
+// T is a type.
+type T struct{}
+
+// Marshal is a method.
+func (T) Marshal() {}
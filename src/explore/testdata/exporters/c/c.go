@@ -0,0 +1,4 @@
+package c
+
+// Marshal is a variable.
+var Marshal = 1
@@ -0,0 +1,4 @@
+package f
+
+// Marshal returns v.
+func Marshal(v int) int { return v }
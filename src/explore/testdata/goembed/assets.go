@@ -0,0 +1,19 @@
+// Package goembed has variables initialized with go:embed directives.
+package goembed
+
+import "embed"
+
+// Greeting is the greeting.
+//
+//go:embed hello.txt
+var Greeting string
+
+// Embedded assets.
+var (
+	// Static holds the static files.
+	//
+	//go:embed static/*.css "hello.txt"
+	Static embed.FS
+
+	Other string
+)
@@ -0,0 +1,15 @@
+package circle
+
+type Circle struct{ r float64 }
+
+func (c *Circle) Area() float64      { return 3 * c.r * c.r }
+func (c *Circle) Perimeter() float64 { return 6 * c.r }
+
+// Half implements the interface with the methods promoted from Circle.
+type Half struct {
+	Circle
+}
+
+type Line struct{ n float64 }
+
+func (l Line) Area() float64 { return 0 }
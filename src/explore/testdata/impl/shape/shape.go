@@ -0,0 +1,16 @@
+package shape
+
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+type Namer interface {
+	Shape
+	Name() string
+}
+
+type Square struct{ side float64 }
+
+func (s Square) Area() float64      { return s.side * s.side }
+func (s Square) Perimeter() float64 { return 4 * s.side }
@@ -0,0 +1,16 @@
+// Package iota has constants declared with iota.
+package iota
+
+// Kind is a kind.
+type Kind int
+
+const (
+	A Kind = iota
+	b
+	C
+	D = 1 << iota
+	E // E has a line comment.
+	F = len(extern) + iota
+)
+
+const extern = "abc"
@@ -0,0 +1,13 @@
+// Package links has doc comments with doc links.
+//
+// The [Value] type implements [fmt.Stringer]. See [Value.String] and
+// [strings.Builder.WriteString].
+package links
+
+import "fmt"
+
+// Value is a value.
+type Value int
+
+// String implements fmt.Stringer.
+func (v Value) String() string { return fmt.Sprint(int(v)) }
@@ -0,0 +1,31 @@
+// Package lists has doc comments with lists.
+//
+// # Options
+//
+// The options are:
+//   - Fast selects the fast path.
+//   - Slow selects the slow path. The slow path is used when the fast path
+//     is not available on the platform.
+//
+// The steps are:
+//
+//  1. Open the file.
+//
+//  2. Read the file.
+//
+// Text after the lists.
+package lists
+
+// F does the steps:
+//  1. one
+//  2. two
+//  3. three
+//  4. four
+//  5. five
+//  6. six
+//  7. seven
+//  8. eight
+//  9. nine
+//  10. ten is the last step and the description of the step is long enough to
+//     wrap.
+func F() {}
@@ -0,0 +1,14 @@
+// Package literals has declarations with literal values.
+package literals
+
+const (
+	Name  = "gopher" // The name.
+	Tab   = '\t'
+	Size  = 1 << 10
+	Ratio = 1.5
+	Raw   = `a
+b`
+)
+
+// Deprecated: Use Name.
+var Old = "old"
@@ -0,0 +1,5 @@
+// Package multi shares its directory with a main package.
+package multi
+
+// Hello returns a greeting.
+func Hello() string { return "hello" }
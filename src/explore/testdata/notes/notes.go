@@ -0,0 +1,23 @@
+// Package notes has notes.
+package notes
+
+// BUG(gary): Read does not handle short reads.
+
+// Read reads.
+func Read() {}
+
+// BUG(gary): Write is slow.
+func Write() {}
+
+// TODO(gary): Add Close.
+
+// Old reads.
+//
+// Deprecated: Use Read.
+func Old() {}
+
+// DEPRECATED(gary): Older is replaced by Read.
+type Older struct {
+	A int
+	B int
+}
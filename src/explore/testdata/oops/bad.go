@@ -0,0 +1,10 @@
+package oops
+
+// X has a bad expression.
+var X, Y = 1 + , 2
+
+// Z is after.
+var Z = S{}
+
+// S is a type.
+type S struct{ A int }
@@ -0,0 +1,11 @@
+// Package oops has declarations that once truncated the page.
+package oops
+
+// T is a type.
+type T int
+
+// M has a parenthesized receiver type.
+func (t *(T)) M(x int) T { return T(x) }
+
+// N has a parenthesized value receiver.
+func (t (T)) N() (Result T) { return t }
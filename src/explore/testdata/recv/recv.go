@@ -0,0 +1,31 @@
+// Package recv has methods with different receivers.
+package recv
+
+// Value is a value type.
+type Value int
+
+// Get has a value receiver.
+func (v Value) Get() int { return int(v) }
+
+// Set has a pointer receiver.
+func (v *Value) Set(x int) { *v = Value(x) }
+
+// Reset has an unnamed receiver.
+func (*Value) Reset() {}
+
+// List is a generic list.
+type List[E any] struct {
+	elems []E
+}
+
+// Push has a generic receiver.
+func (l *List[E]) Push(e E) { l.elems = append(l.elems, e) }
+
+// Pair is a generic pair.
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+// Swap has a receiver with two type parameters.
+func (p Pair[K, V]) Swap() Pair[V, K] { return Pair[V, K]{p.Val, p.Key} }
@@ -0,0 +1,9 @@
+package b
+
+import "example.com/a"
+
+func f() {
+	a.F()
+	_ = a.V
+	a.F()
+}
@@ -0,0 +1,7 @@
+package c
+
+import x "example.com/a"
+
+func g() {
+	x.F()
+}
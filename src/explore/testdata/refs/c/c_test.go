@@ -0,0 +1,12 @@
+package c
+
+import (
+	"testing"
+
+	"example.com/a"
+)
+
+func TestG(t *testing.T) {
+	a := struct{ F func() }{}
+	a.F()
+}
@@ -0,0 +1,7 @@
+package d
+
+import "example.com/other/a"
+
+func h() {
+	a.F()
+}
@@ -0,0 +1,5 @@
+package sub
+
+import "example.com/a"
+
+var v = a.V
@@ -0,0 +1,5 @@
+// Package dep is the local replacement for example.com/dep.
+package dep
+
+// Replaced reports whether the package was loaded from the replacement.
+func Replaced() bool { return true }
@@ -0,0 +1,5 @@
+module example.com/main
+
+require example.com/dep v1.0.0
+
+replace example.com/dep => ./dep
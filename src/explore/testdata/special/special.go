@@ -0,0 +1,13 @@
+// Package special has doc comments with HTML and other special characters.
+//
+// Compare with a < b && b > c.
+//
+// Write <b>bold</b> &amp; "quoted" text.
+//
+// Map<K, V> & List<T>
+//
+// Use List[T] for lists.
+package special
+
+// List is a list.
+type List[T any] []T
@@ -0,0 +1,9 @@
+package syntax
+
+// BeforeError is declared before the syntax error.
+func BeforeError() int { return 1 }
+
+var = 3
+
+// AfterError is declared after the syntax error.
+func AfterError() int { return 2 }
@@ -0,0 +1,4 @@
+package syntax
+
+// Good is declared in a file without syntax errors.
+func Good() {}
@@ -0,0 +1,7 @@
+//go:build integration
+// +build integration
+
+package tags
+
+// Integration is declared when the integration tag is set.
+func Integration() {}
@@ -0,0 +1,5 @@
+// Package tags has declarations in files with build constraints.
+package tags
+
+// Untagged is always declared.
+func Untagged() {}
@@ -0,0 +1,13 @@
+// Package uses has imports used in more than one file.
+package uses
+
+import (
+	"fmt"
+	"strings"
+	_ "unsafe"
+)
+
+// A returns s in upper case.
+func A(s string) string {
+	return strings.ToUpper(fmt.Sprint(s, strings.Repeat("!", 2)))
+}
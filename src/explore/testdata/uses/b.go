@@ -0,0 +1,6 @@
+package uses
+
+import "fmt"
+
+// B prints s.
+func B(s string) { fmt.Println(s) }
@@ -0,0 +1,29 @@
+// Package varvalues has variables initialized with constant expressions.
+package varvalues
+
+import "time"
+
+const size = 1 << 10
+
+var (
+	// Size is computed from an unexported constant.
+	Size = size * 4
+
+	// Name is a literal.
+	Name = "name"
+
+	// Greeting is a constant string expression.
+	Greeting = "hello, " + "world"
+
+	// Timeout uses an imported constant.
+	Timeout = 2 * time.Second
+
+	// Mask has a line comment.
+	Mask = size - 1 // 1023
+
+	// Ratio is a typed constant expression.
+	Ratio float64 = 1 / 4.0
+)
+
+// Now is not constant.
+var Now = time.Now()
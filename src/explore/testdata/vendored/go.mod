@@ -0,0 +1,5 @@
+module example.com/vendored
+
+go 1.17
+
+require example.com/cached v1.10.0
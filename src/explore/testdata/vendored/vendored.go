@@ -0,0 +1,5 @@
+package vendored
+
+import "example.com/cached"
+
+var _ = cached.Vendored
@@ -2,17 +2,17 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package format implements the :Fmt command.
+// Package format implements the :Fmt and :Gofmt commands.
 package format
 
 import (
 	"bytes"
+	"fmt"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"strconv"
 
 	"github.com/garyburd/vigor/src/context"
+	"github.com/garyburd/vigor/src/quickfix"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/neovim/go-client/nvim/plugin"
@@ -20,14 +20,73 @@ import (
 
 func Register(p *plugin.Plugin) {
 	p.HandleCommand(&plugin.CommandOptions{Name: "Fmt", Range: "%", Eval: "*"}, format)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gofmt", Range: "%", Eval: "*"}, gofmt)
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWritePre", Pattern: "*.go", Eval: "*"}, formatOnSave)
 }
 
-var errorPat = regexp.MustCompile(`^([^:]+):(\d+)(?::(\d+))?(.*)`)
-
-func format(v *nvim.Nvim, r [2]int, eval *struct {
+type formatEval struct {
 	Env   context.Env
 	Bufnr int `eval:"bufnr('%')"`
+
+	// Command is the program and arguments used by :Fmt. The default is
+	// goimports -srcdir with the directory of the buffer.
+	Command []string `eval:"get(g:, 'vigor_fmt_command', [])"`
+
+	// Local is the comma separated list of import path prefixes passed to
+	// goimports -local.
+	Local string `eval:"get(g:, 'vigor_goimports_local', '')"`
+}
+
+// format formats the buffer with the command in g:vigor_fmt_command or with
+// goimports, which also updates the imports.
+func format(v *nvim.Nvim, r [2]int, eval *formatEval) error {
+	if len(eval.Command) > 0 {
+		return run(v, eval, func(string) []string { return eval.Command })
+	}
+	return run(v, eval, func(fname string) []string {
+		return goimportsCommand(fname, eval.Local)
+	})
+}
+
+// goimportsCommand returns the goimports command for the file fname. Imports
+// with the local prefixes are grouped after the other imports.
+func goimportsCommand(fname string, local string) []string {
+	argv := []string{"goimports", "-srcdir", filepath.Dir(fname)}
+	if local != "" {
+		argv = append(argv, "-local", local)
+	}
+	return argv
+}
+
+// formatOnSave formats the buffer with :Fmt before the buffer is written
+// when g:vigor_format_on_save is set. The buffer is not changed when the
+// formatter fails. Errors are reported as messages instead of returned so
+// that the write is not interrupted.
+func formatOnSave(v *nvim.Nvim, eval *struct {
+	Enabled bool `eval:"get(g:, 'vigor_format_on_save', 0)"`
+	Abuf    int  `eval:"str2nr(expand('<abuf>'))"`
+	Format  formatEval
 }) error {
+	if !eval.Enabled {
+		return nil
+	}
+	eval.Format.Bufnr = eval.Abuf
+	if err := format(v, [2]int{}, &eval.Format); err != nil {
+		return v.WritelnErr("vigor: format on save: " + err.Error())
+	}
+	return nil
+}
+
+// gofmt formats the buffer with gofmt. The imports are not changed.
+func gofmt(v *nvim.Nvim, r [2]int, eval *formatEval) error {
+	return run(v, eval, func(string) []string { return []string{"gofmt"} })
+}
+
+// run updates the buffer with the output of a command with the buffer as
+// input. The command function returns the program and arguments for the
+// buffer file name. Syntax errors reported by the command are set in the
+// quickfix list.
+func run(v *nvim.Nvim, eval *formatEval, command func(fname string) []string) error {
 	var (
 		in    [][]byte
 		fname string
@@ -42,7 +101,11 @@ func format(v *nvim.Nvim, r [2]int, eval *struct {
 	}
 
 	var stdout, stderr bytes.Buffer
-	c := exec.Command("goimports", "-srcdir", filepath.Dir(fname))
+	argv := command(fname)
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return fmt.Errorf("formatter %s not found in PATH", argv[0])
+	}
+	c := exec.Command(argv[0], argv[1:]...)
 	c.Stdin = bytes.NewReader(bytes.Join(in, []byte{'\n'}))
 	c.Stdout = &stdout
 	c.Stderr = &stderr
@@ -53,60 +116,157 @@ func format(v *nvim.Nvim, r [2]int, eval *struct {
 		return minUpdate(v, buf, in, out)
 	}
 	if _, ok := err.(*exec.ExitError); ok {
-		var qfl []*nvim.QuickfixError
-		for _, m := range errorPat.FindAllSubmatch(stderr.Bytes(), -1) {
-			qfe := nvim.QuickfixError{}
-			qfe.LNum, _ = strconv.Atoi(string(m[2]))
-			qfe.Col, _ = strconv.Atoi(string(m[3]))
-			qfe.Text = string(bytes.TrimSpace(m[4]))
-			qfe.Bufnr = eval.Bufnr
-			qfl = append(qfl, &qfe)
-		}
-		if len(qfl) > 0 {
-			b := v.NewBatch()
-			b.Call("setqflist", nil, qfl)
-			b.Command("cc")
-			return b.Execute()
+		if qfl := quickfix.Parse(stderr.Bytes(), "", eval.Bufnr); len(qfl) > 0 {
+			return quickfix.Set(v, qfl)
 		}
 	}
 	return err
 }
 
+// minUpdate updates buffer b from lines in to lines out using the edits
+// computed by diffLines. Lines outside of the edited regions are not touched.
+// If b is the current buffer, then the cursor and the view of the current
+// window are moved with the lines.
 func minUpdate(v *nvim.Nvim, b nvim.Buffer, in [][]byte, out [][]byte) error {
+	edits := diffLines(in, out)
+	if len(edits) == 0 {
+		return nil
+	}
+
+	var (
+		cur  nvim.Buffer
+		view map[string]int
+	)
+	batch := v.NewBatch()
+	batch.CurrentBuffer(&cur)
+	batch.Eval("winsaveview()", &view)
+	if err := batch.Execute(); err != nil {
+		return err
+	}
 
-	// Find matching head lines.
+	// Apply the edits from the end of the buffer to the start so that the
+	// line numbers in the remaining edits are valid.
 
-	n := len(out)
-	if len(in) < len(out) {
-		n = len(in)
+	batch = v.NewBatch()
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		batch.SetBufferLines(b, e.start, e.end, true, e.lines)
 	}
-	head := 0
-	for ; head < n; head++ {
-		if !bytes.Equal(in[head], out[head]) {
-			break
+	if cur == b {
+		view["lnum"] = adjustLine(edits, view["lnum"])
+		view["topline"] = adjustLine(edits, view["topline"])
+		batch.Call("winrestview", nil, view)
+	}
+	return batch.Execute()
+}
+
+// adjustLine returns the 1-based line number of line after the edits. The
+// line moves by the number of lines inserted and deleted above the line. A
+// line in a replaced region stays at the same offset in the region or moves
+// to the last line of the replacement.
+func adjustLine(edits []edit, line int) int {
+	delta := 0
+	for _, e := range edits {
+		switch {
+		case e.end < line:
+			delta += len(e.lines) - (e.end - e.start)
+		case e.start < line:
+			offset := line - 1 - e.start
+			if offset >= len(e.lines) {
+				offset = len(e.lines) - 1
+			}
+			if offset < 0 {
+				offset = 0
+			}
+			return e.start + 1 + offset + delta
+		default:
+			return line + delta
 		}
 	}
+	return line + delta
+}
+
+// edit replaces lines [start, end) of the input with lines.
+type edit struct {
+	start, end int
+	lines      [][]byte
+}
 
-	// Nothing to do?
+// diffLines returns the minimal edits to transform a to b. The edits are
+// computed with the Myers difference algorithm and are returned in order of
+// increasing line number.
+func diffLines(a, b [][]byte) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
 
-	if head == len(in) && head == len(out) {
-		return nil
+	// Find the length of the shortest edit script. The trace records the
+	// furthest reaching paths at the start of each round for backtracking.
+
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[off-d-1:off+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && bytes.Equal(a[x], b[y]) {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return editsFromTrace(a, b, trace)
+			}
+		}
 	}
+	panic("not reached")
+}
 
-	// Find matching tail lines.
+// editsFromTrace backtracks through the trace computed by diffLines to find
+// the matching lines and returns the edits between the matches.
+func editsFromTrace(a, b [][]byte, trace [][]int) []edit {
+	type match struct{ x, y int }
+	var matches []match
 
-	n -= head
-	tail := 0
-	for ; tail < n; tail++ {
-		if !bytes.Equal(in[len(in)-tail-1], out[len(out)-tail-1]) {
-			break
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// The trace slice for round d holds diagonals -d-1 through d+1.
+		prevX, prevY := 0, 0
+		if d > 0 {
+			v := trace[d]
+			k := x - y
+			prevK := k - 1
+			if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+				prevK = k + 1
+			}
+			prevX = v[prevK+d+1]
+			prevY = prevX - prevK
 		}
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, match{x, y})
+		}
+		x, y = prevX, prevY
 	}
 
-	// Update the buffer.
-
-	start := head
-	end := len(in) - tail
-	repl := out[head : len(out)-tail]
-	return v.SetBufferLines(b, start, end, true, repl)
+	var edits []edit
+	i, j := 0, 0
+	for k := len(matches) - 1; k >= 0; k-- {
+		mt := matches[k]
+		if i < mt.x || j < mt.y {
+			edits = append(edits, edit{start: i, end: mt.x, lines: b[j:mt.y]})
+		}
+		i, j = mt.x+1, mt.y+1
+	}
+	if i < len(a) || j < len(b) {
+		edits = append(edits, edit{start: i, end: len(a), lines: b[j:]})
+	}
+	return edits
 }
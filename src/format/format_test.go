@@ -39,6 +39,10 @@ var minUpdateTests = []struct {
 	{"a/b/c", "a/b//c/d/"},
 	{"a/b/c/d", "a/b//c/d"},
 	{"a/b/c/d", "a/b///c/d"},
+
+	{"a/b/c/d/e", "x/b/c/d/y"},
+	{"a/b/c/d/e/f/g", "a/x/c/d/y/f/g"},
+	{"a/b/c/d/e/f/g", "a/c/d/x/e/g/z"},
 }
 
 func TestMinUpdate(t *testing.T) {
@@ -82,3 +86,85 @@ func TestMinUpdate(t *testing.T) {
 		}
 	}
 }
+
+var diffLinesTests = []struct {
+	in    string
+	out   string
+	edits int
+}{
+	{"", "", 0},
+	{"a/b/c", "a/b/c", 0},
+	{"a", "x", 1},
+	{"a/b/c", "x/y/z", 1},
+	{"a/b/c/d", "a/b/x/c/d", 1},
+	{"a/b/c/d", "a/d", 1},
+	{"a/b/c/d/e", "x/b/c/d/y", 2},
+	{"a/b/c/d/e/f/g", "a/x/c/d/y/f/g", 2},
+	{"a/b/c/d/e/f/g", "a/c/d/x/e/g/z", 4},
+	{"a/b/a/b/a", "b/a/b/a/b", 2},
+}
+
+func TestDiffLines(t *testing.T) {
+	for _, tt := range diffLinesTests {
+		in := bytes.Split([]byte(tt.in), []byte{'/'})
+		out := bytes.Split([]byte(tt.out), []byte{'/'})
+
+		edits := diffLines(in, out)
+		if len(edits) != tt.edits {
+			t.Errorf("%q -> %q returned %d edits, want %d", tt.in, tt.out, len(edits), tt.edits)
+		}
+
+		actual := append([][]byte(nil), in...)
+		for i := len(edits) - 1; i >= 0; i-- {
+			e := edits[i]
+			actual = append(actual[:e.start], append(append([][]byte(nil), e.lines...), actual[e.end:]...)...)
+		}
+		if !reflect.DeepEqual(actual, out) {
+			t.Errorf("%q -> %q applied edits %v, want %v", tt.in, tt.out, actual, out)
+		}
+	}
+}
+
+var adjustLineTests = []struct {
+	in   string
+	out  string
+	line int
+	want int
+}{
+	{"a/b/c", "a/b/c", 2, 2},
+	{"a/b/c/d", "x/y/a/b/c/d", 3, 5},
+	{"a/b/c/d", "a/x/y/b/c/d", 2, 4},
+	{"a/b/c/d", "a/x/y/b/c/d", 1, 1},
+	{"a/b/c/d", "b/c/d", 3, 2},
+	{"a/b/c/d", "a/d", 3, 2},
+	{"a/b/c/d", "a/x/d", 3, 2},
+	{"a/b/c/d/e", "a/x/y/z/e", 3, 3},
+	{"a/b/c/d/e", "x/b/c/d/y", 4, 4},
+}
+
+func TestAdjustLine(t *testing.T) {
+	for _, tt := range adjustLineTests {
+		in := bytes.Split([]byte(tt.in), []byte{'/'})
+		out := bytes.Split([]byte(tt.out), []byte{'/'})
+		if got := adjustLine(diffLines(in, out), tt.line); got != tt.want {
+			t.Errorf("%q -> %q adjustLine(%d) = %d, want %d", tt.in, tt.out, tt.line, got, tt.want)
+		}
+	}
+}
+
+var goimportsCommandTests = []struct {
+	local string
+	want  []string
+}{
+	{"", []string{"goimports", "-srcdir", "/src/p"}},
+	{"github.com/acme", []string{"goimports", "-srcdir", "/src/p", "-local", "github.com/acme"}},
+	{"github.com/acme,example.com", []string{"goimports", "-srcdir", "/src/p", "-local", "github.com/acme,example.com"}},
+}
+
+func TestGoimportsCommand(t *testing.T) {
+	for _, tt := range goimportsCommandTests {
+		if got := goimportsCommand("/src/p/p.go", tt.local); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("goimportsCommand(%q) = %v, want %v", tt.local, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quickfix converts the output of Go tools to quickfix lists.
+package quickfix
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+var errorPat = regexp.MustCompile(`(?m)^([^:\n]+):(\d+)(?::(\d+))?(.*)`)
+
+// Parse returns the "file:line:col: message" errors in p. If bufnr is not
+// zero, then the errors refer to that buffer. Otherwise, the file names are
+// interpreted relative to dir.
+func Parse(p []byte, dir string, bufnr int) []*nvim.QuickfixError {
+	var qfl []*nvim.QuickfixError
+	for _, m := range errorPat.FindAllSubmatch(p, -1) {
+		qfe := nvim.QuickfixError{}
+		qfe.LNum, _ = strconv.Atoi(string(m[2]))
+		qfe.Col, _ = strconv.Atoi(string(m[3]))
+		qfe.Text = string(bytes.TrimSpace(bytes.TrimPrefix(m[4], []byte{':'})))
+		if bufnr != 0 {
+			qfe.Bufnr = bufnr
+		} else {
+			fname := string(m[1])
+			if !filepath.IsAbs(fname) {
+				fname = filepath.Join(dir, fname)
+			}
+			qfe.FileName = fname
+		}
+		qfl = append(qfl, &qfe)
+	}
+	return qfl
+}
+
+// Set replaces the quickfix list with qfl and jumps to the first error.
+func Set(v *nvim.Nvim, qfl []*nvim.QuickfixError) error {
+	b := v.NewBatch()
+	b.Call("setqflist", nil, qfl)
+	b.Command("cc")
+	return b.Execute()
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vet implements the :Govet command.
+package vet
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+
+	"github.com/garyburd/vigor/src/context"
+	"github.com/garyburd/vigor/src/quickfix"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/neovim/go-client/nvim/plugin"
+)
+
+func Register(p *plugin.Plugin) {
+	p.HandleCommand(&plugin.CommandOptions{Name: "Govet", Eval: "*"}, vet)
+}
+
+func vet(v *nvim.Nvim, eval *struct {
+	Env context.Env
+	Dir string `eval:"expand('%:p:h')"`
+}) error {
+	var stderr bytes.Buffer
+	c := exec.Command("go", "vet", ".")
+	c.Dir = eval.Dir
+	c.Stderr = &stderr
+	c.Env = context.Get(&eval.Env).Environ
+	err := c.Run()
+	if err == nil {
+		b := v.NewBatch()
+		b.Call("setqflist", nil, []*nvim.QuickfixError{})
+		b.Command("echo 'go vet: no problems found'")
+		return b.Execute()
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		if qfl := quickfix.Parse(stderr.Bytes(), eval.Dir, 0); len(qfl) > 0 {
+			return quickfix.Set(v, qfl)
+		}
+		return errors.New(string(bytes.TrimSpace(stderr.Bytes())))
+	}
+	return err
+}